@@ -0,0 +1,956 @@
+package bigip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	uriAuth      = "auth"
+	uriPartition = "partition"
+	uriUser      = "user"
+	uriAuthz     = "authz"
+	uriTokens    = "tokens"
+	uriLdap      = "ldap"
+	uriRadius    = "radius"
+	uriRadiusSrv = "radius-server"
+	uriTacacs    = "tacacs"
+	uriSource    = "source"
+	uriRemote    = "remote-role"
+	uriRoleInfo  = "role-info"
+
+	// SystemAuth is the name of the LDAP, RADIUS and TACACS+ configuration
+	// used for system authentication.
+	SystemAuth = "system-auth"
+
+	commonPartition = "Common"
+)
+
+// User roles
+const (
+	RoleAdmin              = "admin"
+	RoleResourceAdmin      = "resource-admin"
+	RoleUserManager        = "user-manager"
+	RoleManager            = "manager"
+	RoleCertificateManager = "certificate-manager"
+	RoleIRuleManager       = "irule-manager"
+	RoleApplicationEditor  = "application-editor"
+	RoleOperator           = "operator"
+	RoleAuditor            = "auditor"
+	RoleGuest              = "guest"
+	RoleNoAccess           = "no-access"
+
+	// AllPartitions grants a role on every partition.
+	AllPartitions = "all-partitions"
+)
+
+// User shells
+const (
+	ShellBash = "bash"
+	ShellTmsh = "tmsh"
+	ShellNone = "none"
+)
+
+// Partitions contains a list of every partition on the BIG-IP system.
+type Partitions struct {
+	Partitions []Partition `json:"items"`
+}
+
+// Partition contains information about each individual partition. You can use
+// all of these fields when modifying a partition.
+type Partition struct {
+	Name               string `json:"name,omitempty"`
+	FullPath           string `json:"fullPath,omitempty"`
+	Generation         int    `json:"generation,omitempty"`
+	DefaultRouteDomain int    `json:"defaultRouteDomain,omitempty"`
+	Description        string `json:"description,omitempty"`
+}
+
+// Users contains a list of every local user on the BIG-IP system.
+type Users struct {
+	Users []User `json:"items"`
+}
+
+// User contains information about each individual local user. You can use
+// all of these fields when modifying a user.
+type User struct {
+	Name        string `json:"name,omitempty"`
+	FullPath    string `json:"fullPath,omitempty"`
+	Generation  int    `json:"generation,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Only used when creating a user or changing the password.
+	Password          string `json:"password,omitempty"`
+	EncryptedPassword string `json:"encryptedPassword,omitempty"`
+	// One of ShellBash, ShellTmsh or ShellNone.
+	Shell           string            `json:"shell,omitempty"`
+	PartitionAccess []PartitionAccess `json:"partitionAccess,omitempty"`
+}
+
+// PartitionAccess grants a user a role on a partition. Use AllPartitions as
+// the name to grant the role on every partition.
+type PartitionAccess struct {
+	Name string `json:"name,omitempty"`
+	Role string `json:"role,omitempty"`
+}
+
+// AuthTokens contains a list of every active API token.
+type AuthTokens struct {
+	AuthTokens []AuthToken `json:"items"`
+}
+
+// AuthToken contains information about an active API token.
+type AuthToken struct {
+	Token            string `json:"token,omitempty"`
+	Name             string `json:"name,omitempty"`
+	UserName         string `json:"userName,omitempty"`
+	AuthProviderName string `json:"authProviderName,omitempty"`
+	Address          string `json:"address,omitempty"`
+	Timeout          int    `json:"timeout,omitempty"`
+	StartTime        string `json:"startTime,omitempty"`
+	ExpirationMicros int64  `json:"expirationMicros,omitempty"`
+	LastUpdateMicros int64  `json:"lastUpdateMicros,omitempty"`
+}
+
+// Expiry returns the time the token expires.
+func (t *AuthToken) Expiry() time.Time {
+	return time.Unix(t.ExpirationMicros/microToSeconds, 0)
+}
+
+type ldapDTO struct {
+	Name                  string   `json:"name,omitempty"`
+	FullPath              string   `json:"fullPath,omitempty"`
+	Generation            int      `json:"generation,omitempty"`
+	Description           string   `json:"description,omitempty"`
+	BindDn                string   `json:"bindDn,omitempty"`
+	BindPw                string   `json:"bindPw,omitempty"`
+	BindTimeout           int      `json:"bindTimeout,omitempty"`
+	CheckHostAttr         string   `json:"checkHostAttr,omitempty" bool:"enabled"`
+	CheckRolesGroup       string   `json:"checkRolesGroup,omitempty" bool:"enabled"`
+	Filter                string   `json:"filter,omitempty"`
+	GroupDn               string   `json:"groupDn,omitempty"`
+	GroupMemberAttribute  string   `json:"groupMemberAttribute,omitempty"`
+	IdleTimeout           int      `json:"idleTimeout,omitempty"`
+	IgnoreAuthInfoUnavail string   `json:"ignoreAuthInfoUnavail,omitempty" bool:"yes"`
+	IgnoreUnknownUser     string   `json:"ignoreUnknownUser,omitempty" bool:"enabled"`
+	LoginAttribute        string   `json:"loginAttribute,omitempty"`
+	Port                  int      `json:"port,omitempty"`
+	Scope                 string   `json:"scope,omitempty"`
+	SearchBaseDn          string   `json:"searchBaseDn,omitempty"`
+	SearchScope           string   `json:"searchScope,omitempty"`
+	SearchTimeout         int      `json:"searchTimeout,omitempty"`
+	Servers               []string `json:"servers,omitempty"`
+	Ssl                   string   `json:"ssl,omitempty"`
+	SslCaCertFile         string   `json:"sslCaCertFile,omitempty"`
+	SslCheckPeer          string   `json:"sslCheckPeer,omitempty" bool:"enabled"`
+	SslCiphers            string   `json:"sslCiphers,omitempty"`
+	SslClientCert         string   `json:"sslClientCert,omitempty"`
+	SslClientKey          string   `json:"sslClientKey,omitempty"`
+	UserTemplate          string   `json:"userTemplate,omitempty"`
+	Version               int      `json:"version,omitempty"`
+	Warnings              string   `json:"warnings,omitempty" bool:"enabled"`
+}
+
+// LDAP contains the LDAP authentication configuration. You can use all of
+// these fields when modifying the configuration.
+type LDAP struct {
+	Name                  string
+	FullPath              string
+	Generation            int
+	Description           string
+	BindDn                string
+	BindPw                string
+	BindTimeout           int
+	CheckHostAttr         *bool
+	CheckRolesGroup       *bool
+	Filter                string
+	GroupDn               string
+	GroupMemberAttribute  string
+	IdleTimeout           int
+	IgnoreAuthInfoUnavail *bool
+	IgnoreUnknownUser     *bool
+	LoginAttribute        string
+	Port                  int
+	// One of "sub", "one" or "base".
+	Scope         string
+	SearchBaseDn  string
+	SearchScope   string
+	SearchTimeout int
+	// Addresses or host names of the LDAP servers.
+	Servers []string
+	// One of "enabled", "disabled" or "start-tls".
+	Ssl           string
+	SslCaCertFile string
+	SslCheckPeer  *bool
+	SslCiphers    string
+	SslClientCert string
+	SslClientKey  string
+	UserTemplate  string
+	Version       int
+	Warnings      *bool
+}
+
+func (l *LDAP) MarshalJSON() ([]byte, error) {
+	var dto ldapDTO
+	marshal(&dto, l)
+	return json.Marshal(dto)
+}
+
+func (l *LDAP) UnmarshalJSON(b []byte) error {
+	var dto ldapDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(l, &dto)
+}
+
+type radiusDTO struct {
+	Name          string   `json:"name,omitempty"`
+	FullPath      string   `json:"fullPath,omitempty"`
+	Generation    int      `json:"generation,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	AccountingBug string   `json:"accountingBug,omitempty" bool:"enabled"`
+	ClientId      string   `json:"clientId,omitempty"`
+	Debug         string   `json:"debug,omitempty" bool:"enabled"`
+	Retries       int      `json:"retries,omitempty"`
+	Servers       []string `json:"servers,omitempty"`
+	ServiceType   string   `json:"serviceType,omitempty"`
+}
+
+// RADIUS contains the RADIUS authentication configuration. You can use all
+// of these fields when modifying the configuration.
+type RADIUS struct {
+	Name          string
+	FullPath      string
+	Generation    int
+	Description   string
+	AccountingBug *bool
+	ClientId      string
+	Debug         *bool
+	Retries       int
+	// Names of the RadiusServer objects to use, primary first.
+	Servers     []string
+	ServiceType string
+}
+
+func (r *RADIUS) MarshalJSON() ([]byte, error) {
+	var dto radiusDTO
+	marshal(&dto, r)
+	return json.Marshal(dto)
+}
+
+func (r *RADIUS) UnmarshalJSON(b []byte) error {
+	var dto radiusDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(r, &dto)
+}
+
+// RadiusServers contains a list of every RADIUS server.
+type RadiusServers struct {
+	RadiusServers []RadiusServer `json:"items"`
+}
+
+// RadiusServer contains information about each individual RADIUS server. You
+// can use all of these fields when modifying a RADIUS server.
+type RadiusServer struct {
+	Name        string `json:"name,omitempty"`
+	Partition   string `json:"partition,omitempty"`
+	FullPath    string `json:"fullPath,omitempty"`
+	Generation  int    `json:"generation,omitempty"`
+	Description string `json:"description,omitempty"`
+	Server      string `json:"server,omitempty"`
+	Port        int    `json:"port,omitempty"`
+	Secret      string `json:"secret,omitempty"`
+	Timeout     int    `json:"timeout,omitempty"`
+}
+
+type tacacsDTO struct {
+	Name        string `json:"name,omitempty"`
+	FullPath    string `json:"fullPath,omitempty"`
+	Generation  int    `json:"generation,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Either "send-to-first-server" or "send-to-all-servers".
+	Accounting string `json:"accounting,omitempty"`
+	// Either "use-first-server" or "use-all-servers".
+	Authentication string   `json:"authentication,omitempty"`
+	Debug          string   `json:"debug,omitempty" bool:"enabled"`
+	Encryption     string   `json:"encryption,omitempty" bool:"enabled"`
+	Protocol       string   `json:"protocol,omitempty"`
+	Secret         string   `json:"secret,omitempty"`
+	Servers        []string `json:"servers,omitempty"`
+	Service        string   `json:"service,omitempty"`
+}
+
+// TACACS contains the TACACS+ authentication configuration. You can use all
+// of these fields when modifying the configuration.
+type TACACS struct {
+	Name        string
+	FullPath    string
+	Generation  int
+	Description string
+	// Either "send-to-first-server" or "send-to-all-servers".
+	Accounting string
+	// Either "use-first-server" or "use-all-servers".
+	Authentication string
+	Debug          *bool
+	Encryption     *bool
+	Protocol       string
+	Secret         string
+	// Addresses or host names of the TACACS+ servers.
+	Servers []string
+	Service string
+}
+
+func (t *TACACS) MarshalJSON() ([]byte, error) {
+	var dto tacacsDTO
+	marshal(&dto, t)
+	return json.Marshal(dto)
+}
+
+func (t *TACACS) UnmarshalJSON(b []byte) error {
+	var dto tacacsDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(t, &dto)
+}
+
+type authSourceDTO struct {
+	Type     string `json:"type,omitempty"`
+	Fallback string `json:"fallback,omitempty" bool:"true"`
+}
+
+// AuthSource selects where users are authenticated. With Fallback set local
+// users can log in when the remote servers are unreachable.
+type AuthSource struct {
+	// One of AuthSourceLocal, AuthSourceLDAP, AuthSourceActiveDirectory,
+	// AuthSourceRADIUS or AuthSourceTACACS.
+	Type     string
+	Fallback *bool
+}
+
+// Authentication source types
+const (
+	AuthSourceLocal           = "local"
+	AuthSourceLDAP            = "ldap"
+	AuthSourceActiveDirectory = "active-directory"
+	AuthSourceRADIUS          = "radius"
+	AuthSourceTACACS          = "tacacs"
+)
+
+func (a *AuthSource) MarshalJSON() ([]byte, error) {
+	var dto authSourceDTO
+	marshal(&dto, a)
+	return json.Marshal(dto)
+}
+
+func (a *AuthSource) UnmarshalJSON(b []byte) error {
+	var dto authSourceDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(a, &dto)
+}
+
+// RemoteRoles contains the remote role mappings.
+type RemoteRoles struct {
+	RemoteRoles []RemoteRole `json:"items"`
+}
+
+type remoteRoleDTO struct {
+	Name          string `json:"name,omitempty"`
+	FullPath      string `json:"fullPath,omitempty"`
+	Generation    int    `json:"generation,omitempty"`
+	Attribute     string `json:"attribute,omitempty"`
+	Console       string `json:"console,omitempty"`
+	Deny          string `json:"deny,omitempty" bool:"enabled"`
+	LineOrder     int    `json:"lineOrder,omitempty"`
+	Role          string `json:"role,omitempty"`
+	UserPartition string `json:"userPartition,omitempty"`
+}
+
+// RemoteRole maps remotely authenticated users to a role. Mappings are
+// evaluated by ascending LineOrder and the first one whose Attribute matches,
+// i.e.: "memberOf=cn=admins,ou=groups,dc=example,dc=com", applies.
+type RemoteRole struct {
+	Name       string
+	FullPath   string
+	Generation int
+	Attribute  string
+	// Shell of the user, one of ShellTmsh or "disabled".
+	Console   string
+	Deny      *bool
+	LineOrder int
+	Role      string
+	// Partition the role applies to, "All" for every partition.
+	UserPartition string
+}
+
+func (r *RemoteRole) MarshalJSON() ([]byte, error) {
+	var dto remoteRoleDTO
+	marshal(&dto, r)
+	return json.Marshal(dto)
+}
+
+func (r *RemoteRole) UnmarshalJSON(b []byte) error {
+	var dto remoteRoleDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(r, &dto)
+}
+
+// partitionObjects is used to enumerate the contents of a partition.
+type partitionObjects struct {
+	Items []struct {
+		Name     string `json:"name,omitempty"`
+		FullPath string `json:"fullPath,omitempty"`
+		ID       int    `json:"id,omitempty"`
+	} `json:"items"`
+}
+
+// partitionContents lists the collections that can hold objects in a
+// partition, in the order they have to be deleted: objects come before the
+// objects they reference.
+var partitionContents = [][]string{
+	{uriLtm, uriVirtual},
+	{uriLtm, uriVirtualAddress},
+	{uriLtm, uriPolicy},
+	{uriLtm, uriPool},
+	{uriLtm, uriNode},
+	{uriLtm, uriSnatPool},
+	{uriLtm, uriIRule},
+	{uriLtm, uriDatagroup, uriInternal},
+	{uriLtm, uriMonitor, uriHttp},
+	{uriLtm, uriMonitor, "https"},
+	{uriLtm, uriMonitor, uriTcp},
+	{uriLtm, uriMonitor, "tcp-half-open"},
+	{uriLtm, uriMonitor, uriUdp},
+	{uriLtm, uriMonitor, "icmp"},
+	{uriLtm, uriMonitor, "gateway-icmp"},
+	{uriLtm, uriMonitor, "external"},
+	{uriLtm, uriProfile, uriHttp},
+	{uriLtm, uriProfile, uriHttpCompression},
+	{uriLtm, uriProfile, uriOneConnect},
+	{uriLtm, uriProfile, uriClientSSL},
+	{uriLtm, uriProfile, uriServerSSL},
+	{uriLtm, uriProfile, uriFtp},
+	{uriLtm, uriProfile, uriTcp},
+	{uriLtm, uriProfile, uriUdp},
+	{uriLtm, uriProfile, uriFastL4},
+	{uriLtm, uriPersistence, uriCookie},
+	{uriLtm, uriPersistence, uriSourceAddr},
+	{uriLtm, uriPersistence, uriHash},
+	{uriSys, uriFile, uriSslCert},
+	{uriSys, uriFile, uriSslKey},
+	{uriNet, uriSelf},
+	{uriNet, uriRoute},
+	{uriNet, uriRouteDomain},
+	{uriNet, uriVlan},
+}
+
+// Partitions returns a list of partitions.
+func (b *BigIP) Partitions() (*Partitions, error) {
+	var partitions Partitions
+	err, _ := b.getForEntity(&partitions, uriAuth, uriPartition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &partitions, nil
+}
+
+// GetPartition retrieves a partition by name. Returns nil if the partition
+// does not exist.
+func (b *BigIP) GetPartition(name string) (*Partition, error) {
+	var partition Partition
+	err, ok := b.getForEntity(&partition, uriAuth, uriPartition, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &partition, nil
+}
+
+// CreatePartition adds a new partition to the BIG-IP system. Objects created
+// in the partition use the route domain <routeDomain> by default.
+func (b *BigIP) CreatePartition(name, description string, routeDomain int) error {
+	config := &Partition{
+		Name:               name,
+		Description:        description,
+		DefaultRouteDomain: routeDomain,
+	}
+
+	return b.post(config, uriAuth, uriPartition)
+}
+
+// AddPartition adds a new partition by config to the BIG-IP system.
+func (b *BigIP) AddPartition(config *Partition) error {
+	return b.post(config, uriAuth, uriPartition)
+}
+
+// DeletePartition removes a partition. The partition has to be empty, use
+// TeardownPartition to remove a partition together with its contents.
+func (b *BigIP) DeletePartition(name string) error {
+	return b.delete(uriAuth, uriPartition, name)
+}
+
+// ModifyPartition allows you to change any attribute of a partition. Fields
+// that can be modified are referenced in the Partition struct. This replaces
+// the existing configuration, so use PatchPartition if you want to change
+// only particular attributes.
+func (b *BigIP) ModifyPartition(name string, config *Partition) error {
+	return b.put(config, uriAuth, uriPartition, name)
+}
+
+// PatchPartition allows you to change any attribute of a partition. This
+// changes only the attributes provided, so use ModifyPartition if you want
+// to replace the existing configuration.
+func (b *BigIP) PatchPartition(name string, config *Partition) error {
+	return b.patch(config, uriAuth, uriPartition, name)
+}
+
+// SetPartitionDefaultRouteDomain changes the default route domain of a
+// partition. Unlike PatchPartition this can reset it to route domain 0.
+func (b *BigIP) SetPartitionDefaultRouteDomain(name string, routeDomain int) error {
+	config := &struct {
+		DefaultRouteDomain int `json:"defaultRouteDomain"`
+	}{routeDomain}
+
+	return b.patch(config, uriAuth, uriPartition, name)
+}
+
+// partitionObjects returns the full paths of the objects in the collection
+// <path> that belong to the partition <name>. Collections that don't exist,
+// i.e. because a module is not provisioned, are empty.
+func (b *BigIP) partitionObjects(name string, path ...string) ([]string, error) {
+	var objects partitionObjects
+	query := fmt.Sprintf("?$filter=partition+eq+%s", name)
+	err, _ := b.getForEntity(&objects, append(path, query)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, o := range objects.Items {
+		// Route domain 0 can't be deleted and is shared by all partitions.
+		if path[len(path)-1] == uriRouteDomain && o.ID == 0 {
+			continue
+		}
+		paths = append(paths, o.FullPath)
+	}
+	return paths, nil
+}
+
+// TeardownPartition deletes every object in the partition <name>, then its
+// folders and the partition itself. Objects are deleted in reverse dependency
+// order, so virtual servers go before the pools they use and so on. Objects
+// of types this library doesn't know about make the final delete fail, which
+// leaves the partition in place.
+func (b *BigIP) TeardownPartition(name string) error {
+	if name == "" || name == commonPartition {
+		return errors.New("The Common partition can't be torn down")
+	}
+
+	partition, err := b.GetPartition(name)
+	if err != nil {
+		return err
+	}
+	if partition == nil {
+		return fmt.Errorf("Partition %s does not exist", name)
+	}
+	if partition.DefaultRouteDomain != 0 {
+		if err := b.SetPartitionDefaultRouteDomain(name, 0); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range partitionContents {
+		objects, err := b.partitionObjects(name, path...)
+		if err != nil {
+			return err
+		}
+		for _, o := range objects {
+			if err := b.delete(append(path, o)...); err != nil {
+				return fmt.Errorf("Failed to delete %s %s: %s", strings.Join(path, "/"), o, err)
+			}
+		}
+	}
+
+	folders, err := b.partitionObjects(name, uriSys, uriFolder)
+	if err != nil {
+		return err
+	}
+	// Delete subfolders deepest first; the partition's own folder goes with
+	// the partition.
+	sort.Slice(folders, func(i, j int) bool {
+		return strings.Count(folders[i], "/") > strings.Count(folders[j], "/")
+	})
+	for _, f := range folders {
+		if f == "/"+name {
+			continue
+		}
+		if err := b.delete(uriSys, uriFolder, f); err != nil {
+			return fmt.Errorf("Failed to delete folder %s: %s", f, err)
+		}
+	}
+
+	return b.DeletePartition(name)
+}
+
+// Users returns a list of local users.
+func (b *BigIP) Users() (*Users, error) {
+	var users Users
+	err, _ := b.getForEntity(&users, uriAuth, uriUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &users, nil
+}
+
+// GetUser retrieves a local user by name. Returns nil if the user does not
+// exist.
+func (b *BigIP) GetUser(name string) (*User, error) {
+	var user User
+	err, ok := b.getForEntity(&user, uriAuth, uriUser, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &user, nil
+}
+
+// CreateUser adds a new local user with the given shell and partition
+// access, i.e.: CreateUser("operator1", "secret", ShellNone,
+// PartitionAccess{Name: "tenant", Role: RoleOperator}).
+func (b *BigIP) CreateUser(name, password, shell string, access ...PartitionAccess) error {
+	config := &User{
+		Name:            name,
+		Password:        password,
+		Shell:           shell,
+		PartitionAccess: access,
+	}
+
+	return b.post(config, uriAuth, uriUser)
+}
+
+// AddUser adds a new local user by config to the BIG-IP system.
+func (b *BigIP) AddUser(config *User) error {
+	return b.post(config, uriAuth, uriUser)
+}
+
+// DeleteUser removes a local user.
+func (b *BigIP) DeleteUser(name string) error {
+	return b.delete(uriAuth, uriUser, name)
+}
+
+// ModifyUser allows you to change any attribute of a local user. Fields that
+// can be modified are referenced in the User struct. This replaces the
+// existing configuration, so use PatchUser if you want to change only
+// particular attributes.
+func (b *BigIP) ModifyUser(name string, config *User) error {
+	return b.put(config, uriAuth, uriUser, name)
+}
+
+// PatchUser allows you to change any attribute of a local user. This changes
+// only the attributes provided, so use ModifyUser if you want to replace the
+// existing configuration.
+func (b *BigIP) PatchUser(name string, config *User) error {
+	return b.patch(config, uriAuth, uriUser, name)
+}
+
+// ChangeUserPassword sets a new password for a local user. Changing the
+// password of the session user invalidates its credentials, so update
+// User/Password of the session afterwards.
+func (b *BigIP) ChangeUserPassword(name, password string) error {
+	config := &User{
+		Password: password,
+	}
+
+	return b.patch(config, uriAuth, uriUser, name)
+}
+
+// AuthTokens returns a list of active API tokens.
+func (b *BigIP) AuthTokens() (*AuthTokens, error) {
+	var tokens AuthTokens
+	err, _ := b.getForEntity(&tokens, uriMgmt, uriShared, uriAuthz, uriTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokens, nil
+}
+
+// RevokeAuthToken revokes an API token. Revoking the token of the session
+// ends it, so only do that when done.
+func (b *BigIP) RevokeAuthToken(token string) error {
+	return b.delete(uriMgmt, uriShared, uriAuthz, uriTokens, token)
+}
+
+// RevokeUserAuthTokens revokes every API token of the user <name> except the
+// token of the session, and returns the number of revoked tokens.
+func (b *BigIP) RevokeUserAuthTokens(name string) (int, error) {
+	tokens, err := b.AuthTokens()
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, t := range tokens.AuthTokens {
+		if t.UserName != name || t.Token == b.Token {
+			continue
+		}
+		if err := b.RevokeAuthToken(t.Token); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// GetLDAP retrieves a LDAP configuration by name, usually SystemAuth.
+// Returns nil if it does not exist.
+func (b *BigIP) GetLDAP(name string) (*LDAP, error) {
+	var config LDAP
+	err, ok := b.getForEntity(&config, uriAuth, uriLdap, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &config, nil
+}
+
+// AddLDAP adds a LDAP configuration. Name it SystemAuth to use it for
+// system authentication.
+func (b *BigIP) AddLDAP(config *LDAP) error {
+	return b.post(config, uriAuth, uriLdap)
+}
+
+// DeleteLDAP removes a LDAP configuration.
+func (b *BigIP) DeleteLDAP(name string) error {
+	return b.delete(uriAuth, uriLdap, name)
+}
+
+// ModifyLDAP replaces a LDAP configuration, use PatchLDAP if you want to
+// change only particular attributes.
+func (b *BigIP) ModifyLDAP(name string, config *LDAP) error {
+	return b.put(config, uriAuth, uriLdap, name)
+}
+
+// PatchLDAP changes only the attributes provided of a LDAP configuration.
+func (b *BigIP) PatchLDAP(name string, config *LDAP) error {
+	return b.patch(config, uriAuth, uriLdap, name)
+}
+
+// GetRADIUS retrieves a RADIUS configuration by name, usually SystemAuth.
+// Returns nil if it does not exist.
+func (b *BigIP) GetRADIUS(name string) (*RADIUS, error) {
+	var config RADIUS
+	err, ok := b.getForEntity(&config, uriAuth, uriRadius, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &config, nil
+}
+
+// AddRADIUS adds a RADIUS configuration. Name it SystemAuth to use it for
+// system authentication.
+func (b *BigIP) AddRADIUS(config *RADIUS) error {
+	return b.post(config, uriAuth, uriRadius)
+}
+
+// DeleteRADIUS removes a RADIUS configuration.
+func (b *BigIP) DeleteRADIUS(name string) error {
+	return b.delete(uriAuth, uriRadius, name)
+}
+
+// ModifyRADIUS replaces a RADIUS configuration, use PatchRADIUS if you want to
+// change only particular attributes.
+func (b *BigIP) ModifyRADIUS(name string, config *RADIUS) error {
+	return b.put(config, uriAuth, uriRadius, name)
+}
+
+// PatchRADIUS changes only the attributes provided of a RADIUS configuration.
+func (b *BigIP) PatchRADIUS(name string, config *RADIUS) error {
+	return b.patch(config, uriAuth, uriRadius, name)
+}
+
+// GetTACACS retrieves a TACACS+ configuration by name, usually SystemAuth.
+// Returns nil if it does not exist.
+func (b *BigIP) GetTACACS(name string) (*TACACS, error) {
+	var config TACACS
+	err, ok := b.getForEntity(&config, uriAuth, uriTacacs, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &config, nil
+}
+
+// AddTACACS adds a TACACS+ configuration. Name it SystemAuth to use it for
+// system authentication.
+func (b *BigIP) AddTACACS(config *TACACS) error {
+	return b.post(config, uriAuth, uriTacacs)
+}
+
+// DeleteTACACS removes a TACACS+ configuration.
+func (b *BigIP) DeleteTACACS(name string) error {
+	return b.delete(uriAuth, uriTacacs, name)
+}
+
+// ModifyTACACS replaces a TACACS+ configuration, use PatchTACACS if you want to
+// change only particular attributes.
+func (b *BigIP) ModifyTACACS(name string, config *TACACS) error {
+	return b.put(config, uriAuth, uriTacacs, name)
+}
+
+// PatchTACACS changes only the attributes provided of a TACACS+ configuration.
+func (b *BigIP) PatchTACACS(name string, config *TACACS) error {
+	return b.patch(config, uriAuth, uriTacacs, name)
+}
+
+// RadiusServers returns a list of RADIUS servers.
+func (b *BigIP) RadiusServers() (*RadiusServers, error) {
+	var servers RadiusServers
+	err, _ := b.getForEntity(&servers, uriAuth, uriRadiusSrv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &servers, nil
+}
+
+// GetRadiusServer retrieves a RADIUS server by name. Returns nil if the
+// server does not exist.
+func (b *BigIP) GetRadiusServer(name string) (*RadiusServer, error) {
+	var server RadiusServer
+	err, ok := b.getForEntity(&server, uriAuth, uriRadiusSrv, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &server, nil
+}
+
+// CreateRadiusServer adds a new RADIUS server at <address> with the shared
+// secret <secret>.
+func (b *BigIP) CreateRadiusServer(name, address string, port int, secret string) error {
+	config := &RadiusServer{
+		Name:   name,
+		Server: address,
+		Port:   port,
+		Secret: secret,
+	}
+
+	return b.post(config, uriAuth, uriRadiusSrv)
+}
+
+// AddRadiusServer adds a new RADIUS server by config.
+func (b *BigIP) AddRadiusServer(config *RadiusServer) error {
+	return b.post(config, uriAuth, uriRadiusSrv)
+}
+
+// DeleteRadiusServer removes a RADIUS server.
+func (b *BigIP) DeleteRadiusServer(name string) error {
+	return b.delete(uriAuth, uriRadiusSrv, name)
+}
+
+// ModifyRadiusServer replaces the configuration of a RADIUS server, use
+// PatchRadiusServer if you want to change only particular attributes.
+func (b *BigIP) ModifyRadiusServer(name string, config *RadiusServer) error {
+	return b.put(config, uriAuth, uriRadiusSrv, name)
+}
+
+// PatchRadiusServer changes only the attributes provided of a RADIUS server.
+func (b *BigIP) PatchRadiusServer(name string, config *RadiusServer) error {
+	return b.patch(config, uriAuth, uriRadiusSrv, name)
+}
+
+// GetAuthSource returns the system authentication source.
+func (b *BigIP) GetAuthSource() (*AuthSource, error) {
+	var source AuthSource
+	err, _ := b.getForEntity(&source, uriAuth, uriSource)
+	if err != nil {
+		return nil, err
+	}
+
+	return &source, nil
+}
+
+// SetAuthSource selects the system authentication source. Configure the
+// source with AddLDAP, AddRADIUS or AddTACACS first. Once set, remote users
+// can log in with NewTokenSession.
+func (b *BigIP) SetAuthSource(sourceType string, fallback bool) error {
+	config := &AuthSource{
+		Type:     sourceType,
+		Fallback: Bool(fallback),
+	}
+
+	return b.patch(config, uriAuth, uriSource)
+}
+
+// RemoteRoles returns the remote role mappings ordered by line order.
+func (b *BigIP) RemoteRoles() (*RemoteRoles, error) {
+	var roles RemoteRoles
+	err, _ := b.getForEntity(&roles, uriAuth, uriRemote, uriRoleInfo)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(roles.RemoteRoles, func(i, j int) bool {
+		return roles.RemoteRoles[i].LineOrder < roles.RemoteRoles[j].LineOrder
+	})
+
+	return &roles, nil
+}
+
+// AddRemoteRole adds a remote role mapping. The mapping is named after its
+// line order unless a name is given.
+func (b *BigIP) AddRemoteRole(config *RemoteRole) error {
+	if config.Name == "" {
+		config.Name = strconv.Itoa(config.LineOrder)
+	}
+	return b.post(config, uriAuth, uriRemote, uriRoleInfo)
+}
+
+// DeleteRemoteRole removes a remote role mapping.
+func (b *BigIP) DeleteRemoteRole(name string) error {
+	return b.delete(uriAuth, uriRemote, uriRoleInfo, name)
+}
+
+// PatchRemoteRole changes only the attributes provided of a remote role
+// mapping, i.e. its line order.
+func (b *BigIP) PatchRemoteRole(name string, config *RemoteRole) error {
+	return b.patch(config, uriAuth, uriRemote, uriRoleInfo, name)
+}
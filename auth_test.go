@@ -0,0 +1,282 @@
+package bigip
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthTestSuite struct {
+	suite.Suite
+	Client          *BigIP
+	Server          *httptest.Server
+	LastRequest     *http.Request
+	LastRequestBody string
+	ResponseFunc    func(http.ResponseWriter, *http.Request)
+}
+
+func (s *AuthTestSuite) SetupSuite() {
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.LastRequestBody = string(body)
+		s.LastRequest = r
+		if s.ResponseFunc != nil {
+			s.ResponseFunc(w, r)
+		}
+	}))
+
+	s.Client = NewSession(s.Server.URL, "", "", nil)
+}
+
+func (s *AuthTestSuite) TearDownSuite() {
+	s.Server.Close()
+}
+
+func (s *AuthTestSuite) SetupTest() {
+	s.ResponseFunc = nil
+	s.LastRequest = nil
+}
+
+func TestAuthSuite(t *testing.T) {
+	suite.Run(t, new(AuthTestSuite))
+}
+
+func (s *AuthTestSuite) TestCreatePartition() {
+	err := s.Client.CreatePartition("tenant", "Tenant partition", 10)
+
+	s.Require().Nil(err, "Error creating partition")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriAuth, uriPartition), s.LastRequest.URL.Path, "Wrong uri to create partition")
+	s.Require().Equal("POST", s.LastRequest.Method)
+	s.Require().JSONEq(`{"name": "tenant", "description": "Tenant partition", "defaultRouteDomain": 10}`, s.LastRequestBody)
+}
+
+func (s *AuthTestSuite) TestSetPartitionDefaultRouteDomain() {
+	err := s.Client.SetPartitionDefaultRouteDomain("tenant", 0)
+
+	s.Require().Nil(err, "Error setting default route domain")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriAuth, uriPartition, "tenant"), s.LastRequest.URL.Path)
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"defaultRouteDomain": 0}`, s.LastRequestBody)
+}
+
+func (s *AuthTestSuite) TestTeardownPartition() {
+	objects := map[string]string{
+		"/mgmt/tm/auth/partition/tenant": `{"name": "tenant", "fullPath": "tenant", "defaultRouteDomain": 10}`,
+		"/mgmt/tm/ltm/virtual":           `{"items": [{"name": "vs", "fullPath": "/tenant/app/vs"}]}`,
+		"/mgmt/tm/ltm/pool":              `{"items": [{"name": "pool", "fullPath": "/tenant/app/pool"}]}`,
+		"/mgmt/tm/ltm/node":              `{"items": [{"name": "10.1.1.1", "fullPath": "/tenant/10.1.1.1"}]}`,
+		"/mgmt/tm/net/route-domain":      `{"items": [{"name": "0", "fullPath": "/Common/0", "id": 0}, {"name": "rd10", "fullPath": "/tenant/rd10", "id": 10}]}`,
+		"/mgmt/tm/net/vlan":              `{"items": [{"name": "vlan10", "fullPath": "/tenant/vlan10"}]}`,
+		"/mgmt/tm/sys/folder":            `{"items": [{"name": "tenant", "fullPath": "/tenant"}, {"name": "app", "fullPath": "/tenant/app"}, {"name": "sub", "fullPath": "/tenant/app/sub"}]}`,
+	}
+	var requests []string
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			if r.URL.Path != "/mgmt/tm/auth/partition/tenant" {
+				s.Equal("$filter=partition+eq+tenant", r.URL.RawQuery)
+			}
+			if body, ok := objects[r.URL.Path]; ok {
+				w.Write([]byte(body))
+			} else {
+				w.Write([]byte(`{"items": []}`))
+			}
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+	}
+
+	err := s.Client.TeardownPartition("tenant")
+
+	s.Require().Nil(err, "Error tearing down partition")
+	s.Require().Equal([]string{
+		"PATCH /mgmt/tm/auth/partition/tenant",
+		"DELETE /mgmt/tm/ltm/virtual/~tenant~app~vs",
+		"DELETE /mgmt/tm/ltm/pool/~tenant~app~pool",
+		"DELETE /mgmt/tm/ltm/node/~tenant~10.1.1.1",
+		"DELETE /mgmt/tm/net/route-domain/~tenant~rd10",
+		"DELETE /mgmt/tm/net/vlan/~tenant~vlan10",
+		"DELETE /mgmt/tm/sys/folder/~tenant~app~sub",
+		"DELETE /mgmt/tm/sys/folder/~tenant~app",
+		"DELETE /mgmt/tm/auth/partition/tenant",
+	}, requests)
+}
+
+func (s *AuthTestSuite) TestTeardownCommonPartition() {
+	err := s.Client.TeardownPartition("Common")
+
+	s.Require().Error(err)
+	s.Require().Nil(s.LastRequest)
+}
+
+func (s *AuthTestSuite) TestCreateUser() {
+	err := s.Client.CreateUser("operator1", "secret", ShellNone,
+		PartitionAccess{Name: "tenant", Role: RoleOperator},
+		PartitionAccess{Name: AllPartitions, Role: RoleGuest})
+
+	s.Require().Nil(err, "Error creating user")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriAuth, uriUser), s.LastRequest.URL.Path, "Wrong uri to create user")
+	s.Require().Equal("POST", s.LastRequest.Method)
+	s.Require().JSONEq(`{"name": "operator1", "password": "secret", "shell": "none", "partitionAccess": [{"name": "tenant", "role": "operator"}, {"name": "all-partitions", "role": "guest"}]}`, s.LastRequestBody)
+}
+
+func (s *AuthTestSuite) TestChangeUserPassword() {
+	err := s.Client.ChangeUserPassword("operator1", "n3w")
+
+	s.Require().Nil(err, "Error changing password")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriAuth, uriUser, "operator1"), s.LastRequest.URL.Path, "Wrong uri to change password")
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"password": "n3w"}`, s.LastRequestBody)
+}
+
+func (s *AuthTestSuite) TestAuthTokens() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+  "items": [
+    {"token": "TOKEN1", "name": "TOKEN1", "userName": "operator1", "authProviderName": "tmos", "timeout": 1200, "expirationMicros": 1500000000000000},
+    {"token": "TOKEN2", "name": "TOKEN2", "userName": "admin", "authProviderName": "tmos", "timeout": 1200, "expirationMicros": 1500000000000000}
+  ],
+  "kind": "shared:authz:tokens:authtokencollectionstate"
+}`))
+	}
+
+	tokens, err := s.Client.AuthTokens()
+
+	s.Require().Nil(err, "Error listing tokens")
+	s.Require().Equal("/mgmt/shared/authz/tokens", s.LastRequest.URL.Path, "Wrong uri to list tokens")
+	s.Require().Len(tokens.AuthTokens, 2)
+	s.Require().Equal("operator1", tokens.AuthTokens[0].UserName)
+	s.Require().Equal(int64(1500000000), tokens.AuthTokens[0].Expiry().Unix())
+}
+
+func (s *AuthTestSuite) TestRevokeUserAuthTokens() {
+	var deleted []string
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			deleted = append(deleted, r.URL.Path)
+			return
+		}
+		w.Write([]byte(`{"items": [
+  {"token": "TOKEN1", "userName": "operator1"},
+  {"token": "TOKEN2", "userName": "admin"},
+  {"token": "TOKEN3", "userName": "operator1"}
+]}`))
+	}
+
+	revoked, err := s.Client.RevokeUserAuthTokens("operator1")
+
+	s.Require().Nil(err, "Error revoking tokens")
+	s.Require().Equal(2, revoked)
+	s.Require().Equal([]string{"/mgmt/shared/authz/tokens/TOKEN1", "/mgmt/shared/authz/tokens/TOKEN3"}, deleted)
+}
+
+func (s *AuthTestSuite) TestGetLDAP() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+  "kind": "tm:auth:ldap:ldapstate",
+  "name": "system-auth",
+  "fullPath": "system-auth",
+  "bindDn": "cn=bigip,dc=example,dc=com",
+  "checkHostAttr": "disabled",
+  "checkRolesGroup": "enabled",
+  "ignoreAuthInfoUnavail": "no",
+  "ignoreUnknownUser": "disabled",
+  "loginAttribute": "samaccountname",
+  "port": 636,
+  "scope": "sub",
+  "searchBaseDn": "dc=example,dc=com",
+  "servers": ["ldap1.example.com", "ldap2.example.com"],
+  "ssl": "enabled",
+  "sslCheckPeer": "enabled",
+  "version": 3,
+  "warnings": "enabled"
+}`))
+	}
+
+	ldap, err := s.Client.GetLDAP(SystemAuth)
+
+	s.Require().Nil(err, "Error getting LDAP configuration")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriAuth, uriLdap, SystemAuth), s.LastRequest.URL.Path, "Wrong uri to fetch LDAP configuration")
+	s.Require().Equal([]string{"ldap1.example.com", "ldap2.example.com"}, ldap.Servers)
+	s.Require().True(*ldap.CheckRolesGroup)
+	s.Require().False(*ldap.IgnoreAuthInfoUnavail)
+	s.Require().True(*ldap.SslCheckPeer)
+	s.Require().Equal(636, ldap.Port)
+}
+
+func (s *AuthTestSuite) TestAddLDAP() {
+	err := s.Client.AddLDAP(&LDAP{
+		Name:                  SystemAuth,
+		Servers:               []string{"ldap1.example.com"},
+		SearchBaseDn:          "dc=example,dc=com",
+		IgnoreAuthInfoUnavail: Bool(true),
+		Ssl:                   "start-tls",
+	})
+
+	s.Require().Nil(err, "Error adding LDAP configuration")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriAuth, uriLdap), s.LastRequest.URL.Path)
+	s.Require().JSONEq(`{"name": "system-auth", "servers": ["ldap1.example.com"], "searchBaseDn": "dc=example,dc=com", "ignoreAuthInfoUnavail": "yes", "ssl": "start-tls"}`, s.LastRequestBody)
+}
+
+func (s *AuthTestSuite) TestRADIUS() {
+	err := s.Client.CreateRadiusServer("radius1", "10.1.1.10", 1812, "secret")
+
+	s.Require().Nil(err, "Error creating RADIUS server")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriAuth, uriRadiusSrv), s.LastRequest.URL.Path, "Wrong uri to create RADIUS server")
+	s.Require().JSONEq(`{"name": "radius1", "server": "10.1.1.10", "port": 1812, "secret": "secret"}`, s.LastRequestBody)
+
+	err = s.Client.AddRADIUS(&RADIUS{Name: SystemAuth, Servers: []string{"radius1"}, Debug: Bool(false)})
+
+	s.Require().Nil(err, "Error adding RADIUS configuration")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriAuth, uriRadius), s.LastRequest.URL.Path, "Wrong uri to add RADIUS configuration")
+	s.Require().JSONEq(`{"name": "system-auth", "servers": ["radius1"], "debug": "disabled"}`, s.LastRequestBody)
+}
+
+func (s *AuthTestSuite) TestPatchTACACS() {
+	err := s.Client.PatchTACACS(SystemAuth, &TACACS{
+		Servers:    []string{"10.1.1.20"},
+		Secret:     "secret",
+		Encryption: Bool(true),
+		Service:    "ppp",
+		Protocol:   "ip",
+	})
+
+	s.Require().Nil(err, "Error patching TACACS+ configuration")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriAuth, uriTacacs, SystemAuth), s.LastRequest.URL.Path)
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"servers": ["10.1.1.20"], "secret": "secret", "encryption": "enabled", "service": "ppp", "protocol": "ip"}`, s.LastRequestBody)
+}
+
+func (s *AuthTestSuite) TestSetAuthSource() {
+	err := s.Client.SetAuthSource(AuthSourceLDAP, true)
+
+	s.Require().Nil(err, "Error setting authentication source")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriAuth, uriSource), s.LastRequest.URL.Path)
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"type": "ldap", "fallback": "true"}`, s.LastRequestBody)
+}
+
+func (s *AuthTestSuite) TestRemoteRoles() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": [
+  {"name": "2000", "attribute": "memberOf=cn=ops", "console": "disabled", "deny": "disabled", "lineOrder": 2000, "role": "operator", "userPartition": "All"},
+  {"name": "1000", "attribute": "memberOf=cn=admins", "console": "tmsh", "deny": "disabled", "lineOrder": 1000, "role": "admin", "userPartition": "All"}
+]}`))
+	}
+
+	roles, err := s.Client.RemoteRoles()
+
+	s.Require().Nil(err, "Error listing remote roles")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriAuth, uriRemote, uriRoleInfo), s.LastRequest.URL.Path)
+	s.Require().Equal(1000, roles.RemoteRoles[0].LineOrder)
+	s.Require().Equal("admin", roles.RemoteRoles[0].Role)
+	s.Require().False(*roles.RemoteRoles[1].Deny)
+
+	err = s.Client.AddRemoteRole(&RemoteRole{Attribute: "memberOf=cn=guests", LineOrder: 3000, Role: RoleGuest, UserPartition: "All", Deny: Bool(false)})
+
+	s.Require().Nil(err, "Error adding remote role")
+	s.Require().JSONEq(`{"name": "3000", "attribute": "memberOf=cn=guests", "lineOrder": 3000, "role": "guest", "userPartition": "All", "deny": "disabled"}`, s.LastRequestBody)
+}
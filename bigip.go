@@ -3,21 +3,29 @@ package bigip
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	microToSeconds  = 1000000 // conversion factor
 	maxTokenTimeout = 36000   // maximum token timeout in seconds
+
+	uriLogin = "mgmt/shared/authn/login"
 )
 
 var defaultConfigOptions = &ConfigOptions{
@@ -33,12 +41,15 @@ type BigIP struct {
 	Host          string
 	User          string
 	Password      string
-	Token         string    // if set, will be used instead of User/Password
-	TokenExpiry   time.Time // the token expiration time
+	Token         string             // if set, will be used instead of User/Password
+	TokenExpiry   time.Time          // the token expiration time
+	Credentials   CredentialProvider // if set, used instead of User/Password
 	Transport     *http.Transport
 	ConfigOptions *ConfigOptions
 	loginProvider string
-	startTime     time.Time // token start time
+	startTime     time.Time    // token start time
+	version       *TMOSVersion // detected on first use, see TMOSVersion
+	versionMu     sync.Mutex
 }
 
 // APIRequest builds our request before sending it to the server.
@@ -117,6 +128,23 @@ func NewTokenSession(host, user, passwd, loginProviderName string, configOptions
 
 // APICall is used to query the BIG-IP web API.
 func (b *BigIP) APICall(options *APIRequest) ([]byte, error) {
+	data, status, err := b.apiCall(options)
+	if status == http.StatusUnauthorized && b.Credentials != nil && options.URL != uriLogin {
+		// The credentials may have been rotated, pick up the current ones
+		// and try once more.
+		b.invalidateCredentials()
+		if b.loginProvider != "" {
+			if err := b.login(); err != nil {
+				return data, err
+			}
+		}
+		data, _, err = b.apiCall(options)
+	}
+
+	return data, err
+}
+
+func (b *BigIP) apiCall(options *APIRequest) ([]byte, int, error) {
 	var req *http.Request
 	client := &http.Client{
 		Transport: b.Transport,
@@ -131,10 +159,8 @@ func (b *BigIP) APICall(options *APIRequest) ([]byte, error) {
 	url := fmt.Sprintf(format, b.Host, options.URL)
 	body := bytes.NewReader([]byte(options.Body))
 	req, _ = http.NewRequest(strings.ToUpper(options.Method), url, body)
-	if b.Token != "" {
-		req.Header.Set("X-F5-Auth-Token", b.Token)
-	} else {
-		req.SetBasicAuth(b.User, b.Password)
+	if err := b.setAuth(req); err != nil {
+		return nil, 0, err
 	}
 
 	// fmt.Println("REQ -- ", options.Method, " ", url, " -- ", options.Body)
@@ -145,7 +171,7 @@ func (b *BigIP) APICall(options *APIRequest) ([]byte, error) {
 
 	res, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	defer res.Body.Close()
@@ -154,14 +180,14 @@ func (b *BigIP) APICall(options *APIRequest) ([]byte, error) {
 
 	if res.StatusCode >= 400 {
 		if res.Header.Get("Content-Type") == "application/json" {
-			return data, b.checkError(data)
+			return data, res.StatusCode, b.checkError(data)
 		}
 
-		return data, fmt.Errorf("HTTP %d :: %s", res.StatusCode, string(data[:]))
+		return data, res.StatusCode, fmt.Errorf("HTTP %d :: %s", res.StatusCode, string(data[:]))
 	}
 
 	// fmt.Println("Resp --", res.StatusCode, " -- ", string(data))
-	return data, nil
+	return data, res.StatusCode, nil
 }
 
 // RefreshTokenSession refreshes the token expiration time by increasing
@@ -221,10 +247,29 @@ func (b *BigIP) patch(body interface{}, path ...string) error {
 }
 
 func (b *BigIP) reqWithBody(method string, body interface{}, path ...string) error {
-	marshalJSON, err := jsonMarshal(body)
+	_, err := b.reqWithBodyForResponse(method, body, path...)
+	return err
+}
+
+// postForEntity posts a body and populates an entity from the response. Commands
+// run through the API (util, tasks, etc.) report their results this way.
+func (b *BigIP) postForEntity(e interface{}, body interface{}, path ...string) error {
+	resp, err := b.reqWithBodyForResponse("post", body, path...)
 	if err != nil {
 		return err
 	}
+	if len(resp) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(resp, e)
+}
+
+func (b *BigIP) reqWithBodyForResponse(method string, body interface{}, path ...string) ([]byte, error) {
+	marshalJSON, err := jsonMarshal(body)
+	if err != nil {
+		return nil, err
+	}
 
 	req := &APIRequest{
 		Method:      method,
@@ -233,8 +278,7 @@ func (b *BigIP) reqWithBody(method string, body interface{}, path ...string) err
 		ContentType: "application/json",
 	}
 
-	_, callErr := b.APICall(req)
-	return callErr
+	return b.APICall(req)
 }
 
 //Get a url and populate an entity. If the entity does not exist (404) then the
@@ -265,6 +309,99 @@ func (b *BigIP) getForEntity(e interface{}, path ...string) (error, bool) {
 	return nil, true
 }
 
+// Stats contains the entries returned by the stats endpoints.
+type Stats struct {
+	Kind     string                `json:"kind,omitempty"`
+	SelfLink string                `json:"selfLink,omitempty"`
+	Entries  map[string]StatsEntry `json:"entries,omitempty"`
+}
+
+// StatsEntry is a single statistic, or a nested group of statistics.
+type StatsEntry struct {
+	Value       int64  `json:"value,omitempty"`
+	Description string `json:"description,omitempty"`
+	NestedStats *Stats `json:"nestedStats,omitempty"`
+}
+
+// records returns the statistics of each object in a stats response. The
+// entries are keyed by the object's selfLink, so they are sorted by key to
+// return the objects in a stable order.
+func (s *Stats) records() []map[string]StatsEntry {
+	keys := make([]string, 0, len(s.Entries))
+	for k := range s.Entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var records []map[string]StatsEntry
+	for _, k := range keys {
+		if nested := s.Entries[k].NestedStats; nested != nil {
+			records = append(records, nested.Entries)
+		}
+	}
+	return records
+}
+
+// Task contains the state of an asynchronous task run under /mgmt/tm/task.
+type Task struct {
+	ID            string `json:"_taskId,omitempty"`
+	State         string `json:"_taskState,omitempty"`
+	ResultMessage string `json:"_taskResultMessage,omitempty"`
+}
+
+const (
+	uriTask = "task"
+
+	taskValidating = "VALIDATING"
+	taskCompleted  = "COMPLETED"
+	taskFailed     = "FAILED"
+)
+
+// taskPollInterval is how often the state of a running task is checked.
+var taskPollInterval = time.Second
+
+// runTask creates an asynchronous task under /mgmt/tm/task/<path>, starts it
+// and waits until it completes, fails or the timeout expires.
+func (b *BigIP) runTask(body interface{}, timeout time.Duration, path ...string) (*Task, error) {
+	deadline := time.Now().Add(timeout)
+	taskPath := append([]string{uriTask}, path...)
+
+	var task Task
+	if err := b.postForEntity(&task, body, taskPath...); err != nil {
+		return nil, err
+	}
+	if task.ID == "" {
+		return nil, errors.New("no task id returned")
+	}
+
+	taskPath = append(taskPath, task.ID)
+	if err := b.put(Task{State: taskValidating}, taskPath...); err != nil {
+		return nil, err
+	}
+
+	for time.Now().Before(deadline) {
+		err, _ := b.getForEntity(&task, taskPath...)
+		if err != nil {
+			return nil, err
+		}
+
+		switch task.State {
+		case taskCompleted:
+			return &task, nil
+		case taskFailed:
+			var result Task
+			b.getForEntity(&result, append(taskPath, "result")...)
+			if result.ResultMessage != "" {
+				task.ResultMessage = result.ResultMessage
+			}
+			return &task, fmt.Errorf("Task %s failed: %s", task.ID, task.ResultMessage)
+		}
+		time.Sleep(taskPollInterval)
+	}
+
+	return &task, fmt.Errorf("Timed out after %s", timeout)
+}
+
 // checkError handles any errors we get from our API requests. It returns either the
 // message of the error, if any, or nil.
 func (b *BigIP) checkError(resp []byte) error {
@@ -334,6 +471,9 @@ func marshal(to, from interface{}) error {
 			case "no", "disabled", "false":
 				toField.Set(reflect.ValueOf(Bool(false)))
 				break
+			case "":
+				// Attribute not returned, leave it unset.
+				break
 			default:
 				return fmt.Errorf("Unknown boolean conversion for %s: %s", toFieldType.Name, fromField.Interface())
 			}
@@ -381,78 +521,446 @@ func toBoolString(b bool, trueStr, falseStr string) string {
 	return falseStr
 }
 
-// Upload a file read from a Reader
-func (b *BigIP) Upload(r io.Reader, size int64, path ...string) (*Upload, error) {
+// UploadOptions controls how a file is uploaded. The zero value uploads the
+// file sequentially in 512KB chunks without retries.
+type UploadOptions struct {
+	// ChunkSize is the number of bytes sent per request (default 512KB). It
+	// must not change when resuming an upload.
+	ChunkSize int64
+	// Concurrency is the number of chunks uploaded in parallel (default 1).
+	Concurrency int
+	// Retries is the number of times a failed chunk is sent again.
+	Retries int
+	// Resume is the status returned by an interrupted upload of the same
+	// file. Chunks the BIG-IP reported in its UsedChunks are not sent again.
+	Resume *Upload
+	// Progress, if set, is called after each chunk with the number of bytes
+	// the BIG-IP has received so far.
+	Progress func(sent, total int64)
+	// Verify compares the size and SHA-256 digest of the uploaded file
+	// against the file the BIG-IP reports in LocalFilePath.
+	Verify bool
+}
+
+// uploadRetryDelay is the pause before a failed chunk is sent again. It grows
+// linearly with each attempt.
+var uploadRetryDelay = time.Second
+
+// Upload a file read from a ReaderAt
+func (b *BigIP) Upload(r io.ReaderAt, size int64, path ...string) (*Upload, error) {
+	return b.UploadWithOptions(r, size, nil, path...)
+}
+
+// UploadWithOptions uploads a file read from a ReaderAt in chunks. If the
+// upload fails the returned Upload is still set when any chunk was received,
+// and can be passed as UploadOptions.Resume to continue where it stopped.
+func (b *BigIP) UploadWithOptions(r io.ReaderAt, size int64, opts *UploadOptions, path ...string) (*Upload, error) {
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	if size <= 0 {
+		return nil, errors.New("cannot upload an empty file")
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 512 * 1024
+	}
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+
 	client := &http.Client{
 		Transport: b.Transport,
 		Timeout:   b.ConfigOptions.APICallTimeout,
 	}
-	options := &APIRequest{
-		Method:      "post",
-		URL:         b.iControlPath(path),
-		ContentType: "application/octet-stream",
+	url := b.fileTransferURL(path...)
+
+	status := &Upload{
+		RemainingByteCount: size,
+		TotalByteCount:     size,
+		UsedChunks:         map[string]int{},
 	}
-	var format string
-	if strings.Contains(options.URL, "mgmt/") {
-		format = "%s/%s"
-	} else {
-		format = "%s/mgmt/%s"
+	if opts.Resume != nil {
+		*status = *opts.Resume
+		status.UsedChunks = map[string]int{}
+		for k, v := range opts.Resume.UsedChunks {
+			status.UsedChunks[k] = v
+		}
 	}
-	url := fmt.Sprintf(format, b.Host, options.URL)
-	chunkSize := 512 * 1024
-	var start, end int64
-	for {
-		// Read next chunk
-		chunk := make([]byte, chunkSize)
-		n, err := r.Read(chunk)
-		if err != nil {
-			return nil, err
+	received := opts.Resume != nil
+	var sent int64
+	var uploadErr error
+	var mu sync.Mutex
+
+	offsets := make(chan int64)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range offsets {
+				mu.Lock()
+				failed := uploadErr != nil
+				mu.Unlock()
+				if failed {
+					continue
+				}
+				end := start + chunkSize
+				if end > size {
+					end = size
+				}
+				upload, err := b.uploadChunkWithRetries(client, url, r, start, end, size, opts.Retries)
+
+				mu.Lock()
+				if err != nil {
+					if uploadErr == nil {
+						uploadErr = err
+					}
+				} else {
+					received = true
+					mergeUpload(status, upload)
+					sent += end - start
+					if opts.Progress != nil {
+						opts.Progress(sent, size)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for start := int64(0); start < size; start += chunkSize {
+		end := start + chunkSize
+		if end > size {
+			end = size
 		}
-		end = start + int64(n)
-		// Resize buffer size to number of bytes read
-		if n < chunkSize {
-			chunk = chunk[:n]
+		mu.Lock()
+		failed := uploadErr != nil
+		if !failed && int64(status.UsedChunks[strconv.FormatInt(start, 10)]) == end-start {
+			// Already received by the BIG-IP in an earlier attempt.
+			sent += end - start
+			if opts.Progress != nil {
+				opts.Progress(sent, size)
+			}
+			mu.Unlock()
+			continue
 		}
-		body := bytes.NewReader(chunk)
-		req, _ := http.NewRequest(strings.ToUpper(options.Method), url, body)
-		if b.Token != "" {
-			req.Header.Set("X-F5-Auth-Token", b.Token)
-		} else {
-			req.SetBasicAuth(b.User, b.Password)
+		mu.Unlock()
+		if failed {
+			break
+		}
+		offsets <- start
+	}
+	close(offsets)
+	wg.Wait()
+
+	if !received {
+		status = nil
+	}
+	if uploadErr != nil {
+		return status, uploadErr
+	}
+
+	if opts.Verify {
+		if status.LocalFilePath == "" {
+			return status, errors.New("unable to verify upload: the BIG-IP did not report the file path")
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, io.NewSectionReader(r, 0, size)); err != nil {
+			return status, err
+		}
+		if err := b.verifyFile(status.LocalFilePath, size, hex.EncodeToString(h.Sum(nil))); err != nil {
+			return status, err
+		}
+	}
+
+	return status, nil
+}
+
+// mergeUpload folds the status reported for one chunk into the overall
+// status. Chunks may complete out of order, so the most advanced report wins.
+func mergeUpload(status, upload *Upload) {
+	for k, v := range upload.UsedChunks {
+		status.UsedChunks[k] = v
+	}
+	if upload.RemainingByteCount <= status.RemainingByteCount {
+		status.RemainingByteCount = upload.RemainingByteCount
+		status.TotalByteCount = upload.TotalByteCount
+		status.LocalFilePath = upload.LocalFilePath
+		status.TemporaryFilePath = upload.TemporaryFilePath
+		status.Generation = upload.Generation
+		status.LastUpdateMicros = upload.LastUpdateMicros
+	}
+}
+
+func (b *BigIP) uploadChunkWithRetries(client *http.Client, url string, r io.ReaderAt, start, end, size int64, retries int) (*Upload, error) {
+	for attempt := 1; ; attempt++ {
+		upload, err := b.uploadChunk(client, url, r, start, end, size)
+		if err == nil || attempt > retries {
+			return upload, err
 		}
-		req.Header.Add("Content-Type", options.ContentType)
-		req.Header.Add("Content-Range", fmt.Sprintf("%d-%d/%d", start, end-1, size))
-		// Try to upload chunk
+		time.Sleep(time.Duration(attempt) * uploadRetryDelay)
+	}
+}
+
+// uploadChunk sends the bytes from start up to, but not including, end.
+func (b *BigIP) uploadChunk(client *http.Client, url string, r io.ReaderAt, start, end, size int64) (*Upload, error) {
+	chunk := make([]byte, end-start)
+	n, err := r.ReadAt(chunk, start)
+	if n < len(chunk) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(chunk))
+	if err := b.setAuth(req); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Range", fmt.Sprintf("%d-%d/%d", start, end-1, size))
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 400 {
+		if res.Header.Get("Content-Type") == "application/json" {
+			if err := b.checkError(data); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, fmt.Errorf("HTTP %d :: %s", res.StatusCode, string(data[:]))
+	}
+
+	var upload Upload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+// Download contains information about a file download.
+type Download struct {
+	// TotalByteCount is the size of the file on the BIG-IP system.
+	TotalByteCount int64
+	// Offset is the position in the file up to which data has been written,
+	// including any bytes written before a resumed download.
+	Offset int64
+	// SHA256 is the hex encoded digest of the file. It is only set once the
+	// whole file has been downloaded and the digest could be computed.
+	SHA256 string
+}
+
+// DownloadOptions controls how a file is downloaded. The zero value
+// downloads the whole file without verification.
+type DownloadOptions struct {
+	// Offset resumes an interrupted download at the given byte offset. The
+	// caller must already have written the bytes before it; Download.Offset
+	// of the interrupted download is the value to pass here.
+	Offset int64
+	// ChunkSize is the number of bytes requested at a time (default 512KB).
+	ChunkSize int64
+	// Hash is a SHA-256 digest that has already been fed the bytes before
+	// Offset. It is required to compute the digest of a resumed download.
+	Hash hash.Hash
+	// Verify compares the size and SHA-256 digest of the downloaded file
+	// against the file at RemotePath on the BIG-IP system.
+	Verify     bool
+	RemotePath string
+}
+
+// Download a file in chunks and write it to a Writer. If the download is
+// interrupted the returned Download is still set, and its Offset can be used
+// to resume.
+func (b *BigIP) Download(w io.Writer, opts *DownloadOptions, path ...string) (*Download, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 512 * 1024
+	}
+	h := opts.Hash
+	if h == nil && opts.Offset == 0 {
+		h = sha256.New()
+	}
+	if opts.Verify && (h == nil || opts.RemotePath == "") {
+		return nil, errors.New("verifying a download requires RemotePath, and Hash when resuming")
+	}
+
+	client := &http.Client{
+		Transport: b.Transport,
+		Timeout:   b.ConfigOptions.APICallTimeout,
+	}
+	url := b.fileTransferURL(path...)
+	dl := &Download{Offset: opts.Offset}
+	sizeKnown := false
+	for !sizeKnown || dl.Offset < dl.TotalByteCount {
+		end := dl.Offset + chunkSize - 1
+		if sizeKnown && end >= dl.TotalByteCount {
+			end = dl.TotalByteCount - 1
+		}
+		req, _ := http.NewRequest("GET", url, nil)
+		if err := b.setAuth(req); err != nil {
+			return dl, err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Content-Range", fmt.Sprintf("%d-%d/%d", dl.Offset, end, dl.TotalByteCount))
 		res, err := client.Do(req)
 		if err != nil {
-			return nil, err
+			return dl, err
+		}
+		data, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return dl, err
+		}
+
+		start, _, total, rangeErr := parseContentRange(res.Header.Get("Content-Range"))
+		if !sizeKnown && rangeErr == nil {
+			// The first response tells us the file size. The device rejects
+			// ranges past the end of the file, so retry with a valid range.
+			dl.TotalByteCount = total
+			sizeKnown = true
+			if res.StatusCode >= 400 && end >= total {
+				continue
+			}
 		}
-		data, _ := ioutil.ReadAll(res.Body)
 		if res.StatusCode >= 400 {
 			if res.Header.Get("Content-Type") == "application/json" {
-				return nil, b.checkError(data)
+				if err := b.checkError(data); err != nil {
+					return dl, err
+				}
 			}
 
-			return nil, fmt.Errorf("HTTP %d :: %s", res.StatusCode, string(data[:]))
+			return dl, fmt.Errorf("HTTP %d :: %s", res.StatusCode, string(data[:]))
+		}
+		if rangeErr != nil {
+			return dl, rangeErr
+		}
+		if start != dl.Offset {
+			return dl, fmt.Errorf("requested bytes from %d but received bytes from %d", dl.Offset, start)
+		}
+		if len(data) == 0 && dl.Offset < dl.TotalByteCount {
+			return dl, fmt.Errorf("no data received at offset %d of %d", dl.Offset, dl.TotalByteCount)
+		}
+
+		n, err := w.Write(data)
+		dl.Offset += int64(n)
+		if h != nil {
+			h.Write(data[:n])
 		}
-		defer res.Body.Close()
-		var upload Upload
-		err = json.Unmarshal(data, &upload)
 		if err != nil {
-			return nil, err
+			return dl, err
 		}
-		start = end
-		if start >= size {
-			// Final chunk was uploaded
-			return &upload, err
+	}
+
+	if h != nil {
+		dl.SHA256 = hex.EncodeToString(h.Sum(nil))
+	}
+	if opts.Verify {
+		if err := b.verifyFile(opts.RemotePath, dl.TotalByteCount, dl.SHA256); err != nil {
+			return dl, err
 		}
 	}
+
+	return dl, nil
+}
+
+// verifyFile checks the size and SHA-256 digest of a file on the BIG-IP system.
+func (b *BigIP) verifyFile(remotePath string, size int64, digest string) error {
+	quoted := quoteArg(remotePath)
+	result, err := b.RunBash(fmt.Sprintf("stat -c %%s %s && sha256sum %s", quoted, quoted))
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("unable to checksum %s: %s", remotePath, strings.TrimSpace(result.Output))
+	}
+	fields := strings.Fields(result.Output)
+	if len(fields) < 2 {
+		return fmt.Errorf("unexpected checksum output for %s: %s", remotePath, result.Output)
+	}
+	if fields[0] != strconv.FormatInt(size, 10) {
+		return fmt.Errorf("size mismatch for %s: local %d, remote %s", remotePath, size, fields[0])
+	}
+	if fields[1] != digest {
+		return fmt.Errorf("checksum mismatch for %s: local %s, remote %s", remotePath, digest, fields[1])
+	}
+
+	return nil
+}
+
+// parseContentRange parses a "start-end/total" Content-Range header.
+func parseContentRange(header string) (start, end, total int64, err error) {
+	header = strings.TrimPrefix(strings.TrimSpace(header), "bytes ")
+	if _, err = fmt.Sscanf(header, "%d-%d/%d", &start, &end, &total); err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+
+	return start, end, total, nil
+}
+
+// fileTransferURL builds the url of a file transfer endpoint. Unlike APICall,
+// paths without a "mgmt/" prefix are relative to /mgmt rather than /mgmt/tm.
+func (b *BigIP) fileTransferURL(path ...string) string {
+	p := b.iControlPath(path)
+	if strings.Contains(p, "mgmt/") {
+		return fmt.Sprintf("%s/%s", b.Host, p)
+	}
+	return fmt.Sprintf("%s/mgmt/%s", b.Host, p)
+}
+
+// setAuth adds the session credentials to a request.
+func (b *BigIP) setAuth(req *http.Request) error {
+	if b.Token != "" {
+		req.Header.Set("X-F5-Auth-Token", b.Token)
+		return nil
+	}
+	creds, err := b.requestCredentials()
+	if err != nil {
+		return err
+	}
+	switch {
+	case creds == nil:
+		req.SetBasicAuth(b.User, b.Password)
+	case creds.Token != "":
+		req.Header.Set("X-F5-Auth-Token", creds.Token)
+	default:
+		req.SetBasicAuth(creds.User, creds.Password)
+	}
+	return nil
 }
 
 // login requests a token.
 func (b *BigIP) login() error {
 	b.Token = ""
 	b.startTime = time.Now()
+	if b.Credentials != nil {
+		creds, err := b.Credentials.Credentials()
+		if err != nil {
+			return err
+		}
+		b.User = creds.User
+		b.Password = creds.Password
+		if creds.Token != "" {
+			// A token issued elsewhere; its expiry is unknown, so
+			// RefreshTokenSession asks the provider again.
+			b.Token = creds.Token
+			b.TokenExpiry = time.Time{}
+			return nil
+		}
+	}
 	type authReq struct {
 		Username          string `json:"username"`
 		Password          string `json:"password"`
@@ -478,7 +986,7 @@ func (b *BigIP) login() error {
 
 	req := &APIRequest{
 		Method:      "post",
-		URL:         "mgmt/shared/authn/login",
+		URL:         uriLogin,
 		Body:        string(marshalJSON),
 		ContentType: "application/json",
 	}
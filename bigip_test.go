@@ -153,3 +153,22 @@ const refreshResponse = `{
   "selfLink": "https://localhost/mgmt/shared/authz/tokens/KZ44TOKEN7SNOTZNR7D7UP24SC"
 }
 `
+
+func TestMarshalUnsetBool(t *testing.T) {
+	dto := struct {
+		AutoSync       string `bool:"enabled"`
+		SaveOnAutoSync string `bool:"true"`
+	}{AutoSync: "enabled"}
+	var model struct {
+		AutoSync       *bool
+		SaveOnAutoSync *bool
+	}
+
+	require.NoError(t, marshal(&model, &dto))
+	require.NotNil(t, model.AutoSync)
+	assert.True(t, *model.AutoSync)
+	assert.Nil(t, model.SaveOnAutoSync)
+
+	dto.SaveOnAutoSync = "sometimes"
+	assert.Error(t, marshal(&model, &dto))
+}
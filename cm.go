@@ -1,10 +1,15 @@
 package bigip
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
+	"regexp"
 	"strings"
+	"time"
 )
 
 // Devices contains a list of every device on the BIG-IP system.
@@ -12,17 +17,286 @@ type Devices struct {
 	Devices []Device `json:"items"`
 }
 
-
 // Device contains information about each individual device.
 type Device struct {
-	Name          string `json:"name,omitempty"`
-	Partition     string `json:"partition,omitempty"`
-	FullPath      string `json:"fullPath,omitempty"`
-	Generation    int    `json:"generation,omitempty"`
-	FailoverState string `json:"failoverState,omitempty"`
-	Hostname      string `json:"hostname,omitempty"`
-	ManagementIp  string `json:"managementIp,omitempty"`
-	SelfDevice    string `json:"selfDevice,omitempty"`
+	Name          string        `json:"name,omitempty"`
+	Partition     string        `json:"partition,omitempty"`
+	FullPath      string        `json:"fullPath,omitempty"`
+	Generation    int           `json:"generation,omitempty"`
+	FailoverState FailoverState `json:"failoverState,omitempty"`
+	Hostname      string        `json:"hostname,omitempty"`
+	ManagementIp  string        `json:"managementIp,omitempty"`
+	SelfDevice    string        `json:"selfDevice,omitempty"`
+	BaseMac       string        `json:"baseMac,omitempty"`
+	Build         string        `json:"build,omitempty"`
+	Cert          string        `json:"cert,omitempty"`
+	ChassisId     string        `json:"chassisId,omitempty"`
+	ConfigsyncIp  string        `json:"configsyncIp,omitempty"`
+	Edition       string        `json:"edition,omitempty"`
+	Key           string        `json:"key,omitempty"`
+	MarketingName string        `json:"marketingName,omitempty"`
+	PlatformId    string        `json:"platformId,omitempty"`
+	Product       string        `json:"product,omitempty"`
+	TimeZone      string        `json:"timeZone,omitempty"`
+	Version       string        `json:"version,omitempty"`
+}
+
+// TrustDomain contains the devices in the device trust domain. Devices are
+// referenced by full path, i.e.: /Common/bigip1.example.com.
+type TrustDomain struct {
+	Name         string   `json:"name,omitempty"`
+	Partition    string   `json:"partition,omitempty"`
+	FullPath     string   `json:"fullPath,omitempty"`
+	Generation   int      `json:"generation,omitempty"`
+	CaDevices    []string `json:"caDevices,omitempty"`
+	Status       string   `json:"status,omitempty"`
+	TrustDevices []string `json:"trustDevices,omitempty"`
+	TrustGroup   string   `json:"trustGroup,omitempty"`
+}
+
+// TrustedDevice is a device in the trust domain. The certificate details
+// are available through the embedded Device. CertificateAuthority is set
+// for devices that can sign the certificates of other devices.
+type TrustedDevice struct {
+	Device
+	CertificateAuthority bool
+}
+
+// TrustCommand is the body used to add or remove devices from the trust domain.
+type TrustCommand struct {
+	Command    string `json:"command,omitempty"`
+	Name       string `json:"name,omitempty"`
+	CaDevice   bool   `json:"caDevice,omitempty"`
+	Device     string `json:"device,omitempty"`
+	DeviceName string `json:"deviceName,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+}
+
+// DeviceGroups contains a list of every device group on the BIG-IP system.
+type DeviceGroups struct {
+	DeviceGroups []DeviceGroup `json:"items"`
+}
+
+type deviceGroupDTO struct {
+	Name        string `json:"name,omitempty"`
+	Partition   string `json:"partition,omitempty"`
+	FullPath    string `json:"fullPath,omitempty"`
+	Generation  int    `json:"generation,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Either "sync-failover" or "sync-only".
+	Type                         string              `json:"type,omitempty"`
+	AsmSync                      string              `json:"asmSync,omitempty" bool:"enabled"`
+	AutoSync                     string              `json:"autoSync,omitempty" bool:"enabled"`
+	FullLoadOnSync               string              `json:"fullLoadOnSync,omitempty" bool:"true"`
+	IncrementalConfigSyncSizeMax int                 `json:"incrementalConfigSyncSizeMax,omitempty"`
+	NetworkFailover              string              `json:"networkFailover,omitempty" bool:"enabled"`
+	SaveOnAutoSync               string              `json:"saveOnAutoSync,omitempty" bool:"true"`
+	Devices                      []DeviceGroupDevice `json:"devices,omitempty"`
+}
+
+// DeviceGroup contains information about each individual device group. You
+// can use all of these fields when modifying a device group.
+type DeviceGroup struct {
+	Name        string
+	Partition   string
+	FullPath    string
+	Generation  int
+	Description string
+	// Either DeviceGroupSyncFailover or DeviceGroupSyncOnly.
+	Type                         string
+	AsmSync                      *bool
+	AutoSync                     *bool
+	FullLoadOnSync               *bool
+	IncrementalConfigSyncSizeMax int
+	NetworkFailover              *bool
+	SaveOnAutoSync               *bool
+	// Only used when creating or modifying a device group. Use
+	// DeviceGroupDevices to list the members.
+	Devices []DeviceGroupDevice
+}
+
+// Device group types
+const (
+	DeviceGroupSyncFailover = "sync-failover"
+	DeviceGroupSyncOnly     = "sync-only"
+)
+
+func (g *DeviceGroup) MarshalJSON() ([]byte, error) {
+	var dto deviceGroupDTO
+	marshal(&dto, g)
+	return json.Marshal(dto)
+}
+
+func (g *DeviceGroup) UnmarshalJSON(b []byte) error {
+	var dto deviceGroupDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(g, &dto)
+}
+
+// DeviceGroupDevices contains the member devices of a device group.
+type DeviceGroupDevices struct {
+	DeviceGroupDevices []DeviceGroupDevice `json:"items"`
+}
+
+// DeviceGroupDevice is a member device of a device group.
+type DeviceGroupDevice struct {
+	Name       string `json:"name,omitempty"`
+	Partition  string `json:"partition,omitempty"`
+	FullPath   string `json:"fullPath,omitempty"`
+	Generation int    `json:"generation,omitempty"`
+}
+
+// TrafficGroups contains a list of every traffic group on the BIG-IP system.
+type TrafficGroups struct {
+	TrafficGroups []TrafficGroup `json:"items"`
+}
+
+type trafficGroupDTO struct {
+	Name                string `json:"name,omitempty"`
+	Partition           string `json:"partition,omitempty"`
+	FullPath            string `json:"fullPath,omitempty"`
+	Generation          int    `json:"generation,omitempty"`
+	Description         string `json:"description,omitempty"`
+	AutoFailbackEnabled string `json:"autoFailbackEnabled,omitempty" bool:"true"`
+	AutoFailbackTime    int    `json:"autoFailbackTime,omitempty"`
+	DefaultDevice       string `json:"defaultDevice,omitempty"`
+	// One of "ha-order", "ha-score" or "load-aware".
+	FailoverMethod string   `json:"failoverMethod,omitempty"`
+	HaGroup        string   `json:"haGroup,omitempty"`
+	HaLoadFactor   int      `json:"haLoadFactor,omitempty"`
+	HaOrder        []string `json:"haOrder,omitempty"`
+	IsFloating     string   `json:"isFloating,omitempty" bool:"true"`
+	Mac            string   `json:"mac,omitempty"`
+	UnitId         int      `json:"unitId,omitempty"`
+}
+
+// TrafficGroup contains information about each individual traffic group. You
+// can use all of these fields when modifying a traffic group.
+type TrafficGroup struct {
+	Name                string
+	Partition           string
+	FullPath            string
+	Generation          int
+	Description         string
+	AutoFailbackEnabled *bool
+	// Seconds to wait before failing back to the first device in HaOrder.
+	AutoFailbackTime int
+	DefaultDevice    string
+	// One of TrafficGroupHAOrder, TrafficGroupHAScore or TrafficGroupLoadAware.
+	FailoverMethod string
+	HaGroup        string
+	HaLoadFactor   int
+	// Devices in the order they should take over the traffic group.
+	HaOrder    []string
+	IsFloating *bool
+	// MAC masquerade address, "none" to disable.
+	Mac    string
+	UnitId int
+}
+
+// Traffic group failover methods
+const (
+	TrafficGroupHAOrder   = "ha-order"
+	TrafficGroupHAScore   = "ha-score"
+	TrafficGroupLoadAware = "load-aware"
+)
+
+func (g *TrafficGroup) MarshalJSON() ([]byte, error) {
+	var dto trafficGroupDTO
+	marshal(&dto, g)
+	return json.Marshal(dto)
+}
+
+func (g *TrafficGroup) UnmarshalJSON(b []byte) error {
+	var dto trafficGroupDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(g, &dto)
+}
+
+// TrafficGroupStatus reports the failover state of a traffic group on one
+// device. Each traffic group has a status for every device in the device group.
+type TrafficGroupStatus struct {
+	TrafficGroup  string
+	Device        string
+	FailoverState FailoverState
+	// Set on the device that takes over the traffic group on failover.
+	NextActive bool
+}
+
+// SyncStatus contains the config sync status of the BIG-IP system.
+type SyncStatus struct {
+	Color   string
+	Mode    string
+	Status  string
+	Summary string
+	// Details are the raw status lines, which Groups and Devices are parsed from.
+	Details []string
+	Groups  []DeviceGroupSyncStatus
+	Devices []DeviceSyncStatus
+}
+
+// DeviceGroupSyncStatus is the config sync status of a device group, i.e.
+// Status "In Sync" or "Changes Pending".
+type DeviceGroupSyncStatus struct {
+	Name    string
+	Status  string
+	Message string
+	// ConflictingDevices are the devices the status message refers to, i.e.
+	// the devices with pending changes.
+	ConflictingDevices []string
+}
+
+// DeviceSyncStatus is the connection status of a device, i.e. "connected".
+type DeviceSyncStatus struct {
+	Name   string
+	Status string
+}
+
+const (
+	syncStatusInSync      = "In Sync"
+	syncStatusSyncFailure = "Sync Failure"
+)
+
+var (
+	syncGroupDetail  = regexp.MustCompile(`^(\S+) \(([^)]+)\): (.*)$`)
+	syncDeviceDetail = regexp.MustCompile(`^(\S+): (.*)$`)
+)
+
+// FailoverState is the high availability state of a device.
+type FailoverState string
+
+// Failover states
+const (
+	FailoverStateActive        FailoverState = "active"
+	FailoverStateStandby       FailoverState = "standby"
+	FailoverStateOffline       FailoverState = "offline"
+	FailoverStateForcedOffline FailoverState = "forced-offline"
+)
+
+// Settled reports whether a device is done changing its failover state.
+// Devices are offline while booting, but forced offline only on request.
+func (s FailoverState) Settled() bool {
+	switch s {
+	case FailoverStateActive, FailoverStateStandby, FailoverStateForcedOffline:
+		return true
+	}
+	return false
+}
+
+// FailoverCommand is used to change the failover state of the device.
+type FailoverCommand struct {
+	Command      string `json:"command"`
+	Standby      bool   `json:"standby,omitempty"`
+	Offline      bool   `json:"offline,omitempty"`
+	Online       bool   `json:"online,omitempty"`
+	TrafficGroup string `json:"trafficGroup,omitempty"`
 }
 
 type ConfigSync struct {
@@ -31,10 +305,28 @@ type ConfigSync struct {
 }
 
 const (
+	uriMgmt                 = "mgmt"
 	uriCm                   = "cm"
+	uriFailover             = "failover"
+	uriDeviceGroup          = "device-group"
+	uriDevices              = "devices"
+	uriSyncStatus           = "sync-status"
 	uriDevice               = "device"
+	uriTrustDomain          = "trust-domain"
+	uriTrafficGroup         = "traffic-group"
+	uriStats                = "stats"
+	uriAddToTrust           = "add-to-trust"
+	uriRemoveFromTrust      = "remove-from-trust"
 	uriAutodeploy           = "autodeploy"
 	uriSoftwareImageUploads = "software-image-uploads"
+	uriQkview               = "qkview"
+	uriQkviewDownload       = "qkview-download"
+
+	qkviewInProgress = "IN_PROGRESS"
+	qkviewSucceeded  = "SUCCEEDED"
+	qkviewFailed     = "FAILED"
+
+	trustDomainRoot = "Root"
 )
 
 // Devices returns a list of devices.
@@ -64,12 +356,160 @@ func (b *BigIP) GetCurrentDevice() (*Device, error) {
 	return nil, errors.New("could not find this device")
 }
 
+// GetDevice retrieves a device by name. Returns nil if the device does not exist.
+func (b *BigIP) GetDevice(name string) (*Device, error) {
+	var device Device
+	err, ok := b.getForEntity(&device, uriCm, uriDevice, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &device, nil
+}
+
+// ForceStandby makes this device standby, so a peer takes over. If
+// trafficGroup is set only that traffic group fails over, otherwise all do.
+func (b *BigIP) ForceStandby(trafficGroup string) error {
+	config := &FailoverCommand{
+		Command:      "run",
+		Standby:      true,
+		TrafficGroup: trafficGroup,
+	}
+	return b.post(config, uriSys, uriFailover)
+}
+
+// ForceOffline takes this device offline. It stops processing traffic until
+// ReleaseOffline is called.
+func (b *BigIP) ForceOffline() error {
+	config := &FailoverCommand{
+		Command: "run",
+		Offline: true,
+	}
+	return b.post(config, uriSys, uriFailover)
+}
+
+// ReleaseOffline brings a device taken offline with ForceOffline back online.
+func (b *BigIP) ReleaseOffline() error {
+	config := &FailoverCommand{
+		Command: "run",
+		Online:  true,
+	}
+	return b.post(config, uriSys, uriFailover)
+}
+
+// WaitForFailoverState waits until the device <name> reports <state>, i.e.
+// until a peer became active after ForceStandby.
+func (b *BigIP) WaitForFailoverState(name string, state FailoverState, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var current FailoverState
+	for time.Now().Before(deadline) {
+		device, err := b.GetDevice(name)
+		if err != nil {
+			return err
+		}
+		if device == nil {
+			return fmt.Errorf("Device %s does not exist", name)
+		}
+		current = device.FailoverState
+		if current == state {
+			return nil
+		}
+		time.Sleep(taskPollInterval)
+	}
+
+	return fmt.Errorf("Timed out after %s, %s is %s instead of %s", timeout, name, current, state)
+}
+
+// TrustDomain returns the device trust domain.
+func (b *BigIP) TrustDomain() (*TrustDomain, error) {
+	var domain TrustDomain
+	err, _ := b.getForEntity(&domain, uriCm, uriTrustDomain, trustDomainRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain, nil
+}
+
+// TrustedDevices returns every device in the trust domain with its
+// certificate details.
+func (b *BigIP) TrustedDevices() ([]TrustedDevice, error) {
+	domain, err := b.TrustDomain()
+	if err != nil {
+		return nil, err
+	}
+	devices, err := b.Devices()
+	if err != nil {
+		return nil, err
+	}
+
+	ca := make(map[string]bool)
+	for _, d := range domain.CaDevices {
+		ca[d] = true
+	}
+	var trusted []TrustedDevice
+	for _, name := range domain.TrustDevices {
+		for _, d := range devices.Devices {
+			if d.FullPath == name || d.Name == name {
+				trusted = append(trusted, TrustedDevice{Device: d, CertificateAuthority: ca[name]})
+				break
+			}
+		}
+	}
+
+	return trusted, nil
+}
+
+// AddTrustedDevice adds the peer at management address <address> to the trust
+// domain under the name <name>. The credentials are those of an administrator
+// on the peer. The peer becomes a certificate authority, so it can take over
+// trust management if this device fails.
+func (b *BigIP) AddTrustedDevice(address, name, username, password string) error {
+	config := &TrustCommand{
+		Command:    "run",
+		Name:       trustDomainRoot,
+		CaDevice:   true,
+		Device:     address,
+		DeviceName: name,
+		Username:   username,
+		Password:   password,
+	}
+	return b.post(config, uriCm, uriAddToTrust)
+}
+
+// RemoveTrustedDevice removes the device <name> from the trust domain.
+func (b *BigIP) RemoveTrustedDevice(name string) error {
+	config := &TrustCommand{
+		Command:    "run",
+		Name:       trustDomainRoot,
+		DeviceName: name,
+	}
+	return b.post(config, uriCm, uriRemoveFromTrust)
+}
+
+// ResetTrust removes every peer from the trust domain, leaving this device
+// in a trust domain of its own. There is no REST endpoint for this, so it
+// runs through tmsh.
+func (b *BigIP) ResetTrust() error {
+	result, err := b.RunTmsh("delete cm trust-domain all")
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("Failed to reset device trust: %s", strings.TrimSpace(result.Output))
+	}
+
+	return nil
+}
+
 // ConfigSyncToGroup runs command config-sync to-group <attr>
 func (b *BigIP) ConfigSyncToGroup(name string) error {
-	args := "config-sync to-group "+name
 	config := &ConfigSync{
-		Command: "run",
-		UtilCmdArgs: args,
+		Command:     "run",
+		UtilCmdArgs: "config-sync to-group " + quoteArg(name),
 	}
 	return b.post(config, uriCm)
 }
@@ -86,3 +526,420 @@ func (b *BigIP) UploadSoftwareImage(f *os.File) (*Upload, error) {
 	}
 	return b.Upload(f, info.Size(), uriCm, uriAutodeploy, uriSoftwareImageUploads, info.Name())
 }
+
+// Qkviews contains a list of the qkview files generated through the API.
+type Qkviews struct {
+	Qkviews []Qkview `json:"items"`
+}
+
+// Qkview contains the generation status of a qkview file.
+type Qkview struct {
+	ID               string `json:"id,omitempty"`
+	Name             string `json:"name,omitempty"`
+	Status           string `json:"status,omitempty"`
+	Generation       int    `json:"generation,omitempty"`
+	LastUpdateMicros int    `json:"lastUpdateMicros,omitempty"`
+	SelfLink         string `json:"selfLink,omitempty"`
+}
+
+// Qkviews returns a list of qkviews.
+func (b *BigIP) Qkviews() (*Qkviews, error) {
+	var qkviews Qkviews
+	err, _ := b.getForEntity(&qkviews, uriMgmt, uriCm, uriAutodeploy, uriQkview)
+	if err != nil {
+		return nil, err
+	}
+
+	return &qkviews, nil
+}
+
+// CreateQkview starts generating a qkview file called <name>. Use GetQkview
+// with the returned ID to follow its progress.
+func (b *BigIP) CreateQkview(name string) (*Qkview, error) {
+	var qkview Qkview
+	config := &Qkview{
+		Name: name,
+	}
+	if err := b.postForEntity(&qkview, config, uriMgmt, uriCm, uriAutodeploy, uriQkview); err != nil {
+		return nil, err
+	}
+
+	return &qkview, nil
+}
+
+// GetQkview retrieves a qkview by ID. Returns nil if the qkview does not exist.
+func (b *BigIP) GetQkview(id string) (*Qkview, error) {
+	var qkview Qkview
+	err, ok := b.getForEntity(&qkview, uriMgmt, uriCm, uriAutodeploy, uriQkview, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &qkview, nil
+}
+
+// DeleteQkview removes a qkview and its file from the BIG-IP system.
+func (b *BigIP) DeleteQkview(id string) error {
+	return b.delete(uriMgmt, uriCm, uriAutodeploy, uriQkview, id)
+}
+
+// DownloadQkview downloads the qkview file <name>. opts may be nil.
+func (b *BigIP) DownloadQkview(w io.Writer, name string, opts *DownloadOptions) (*Download, error) {
+	return b.Download(w, opts, uriCm, uriAutodeploy, uriQkviewDownload, name)
+}
+
+// GenerateQkview generates a qkview file called <name>, waits for it to
+// complete and writes it to w. The file is removed from the BIG-IP system
+// afterwards if <remove> is true, and if generating it fails. A qkview whose
+// download fails is kept unless <remove> is true, so the download can be
+// resumed with DownloadQkview at the offset of the returned Download.
+func (b *BigIP) GenerateQkview(w io.Writer, name string, remove bool, timeout time.Duration) (dl *Download, err error) {
+	deadline := time.Now().Add(timeout)
+	qkview, err := b.CreateQkview(name)
+	if err != nil {
+		return nil, err
+	}
+	id := qkview.ID
+	generated := false
+	defer func() {
+		if err != nil && (remove || !generated) {
+			// Keep the original error, the file may already be gone.
+			b.DeleteQkview(id)
+		} else if err == nil && remove {
+			err = b.DeleteQkview(id)
+		}
+	}()
+
+	for qkview.Status != qkviewSucceeded {
+		switch qkview.Status {
+		case qkviewInProgress, "":
+		case qkviewFailed:
+			return nil, fmt.Errorf("Qkview generation failed for %s", name)
+		default:
+			return nil, fmt.Errorf("Unknown qkview status: %s", qkview.Status)
+		}
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("Timed out after %s", timeout)
+		}
+		time.Sleep(taskPollInterval)
+
+		qkview, err = b.GetQkview(id)
+		if err != nil {
+			return nil, err
+		}
+		if qkview == nil {
+			return nil, fmt.Errorf("Qkview %s disappeared while generating", name)
+		}
+	}
+	generated = true
+
+	return b.DownloadQkview(w, qkview.Name, nil)
+}
+
+// DeviceGroups returns a list of device groups.
+func (b *BigIP) DeviceGroups() (*DeviceGroups, error) {
+	var groups DeviceGroups
+	err, _ := b.getForEntity(&groups, uriCm, uriDeviceGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	return &groups, nil
+}
+
+// GetDeviceGroup retrieves a device group by name. Returns nil if the device
+// group does not exist.
+func (b *BigIP) GetDeviceGroup(name string) (*DeviceGroup, error) {
+	var group DeviceGroup
+	err, ok := b.getForEntity(&group, uriCm, uriDeviceGroup, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &group, nil
+}
+
+// CreateDeviceGroup adds a new device group of type DeviceGroupSyncFailover
+// or DeviceGroupSyncOnly with the given member devices.
+func (b *BigIP) CreateDeviceGroup(name, groupType string, devices ...string) error {
+	config := &DeviceGroup{
+		Name: name,
+		Type: groupType,
+	}
+	for _, d := range devices {
+		config.Devices = append(config.Devices, DeviceGroupDevice{Name: d})
+	}
+
+	return b.post(config, uriCm, uriDeviceGroup)
+}
+
+// AddDeviceGroup adds a new device group by config to the BIG-IP system.
+func (b *BigIP) AddDeviceGroup(config *DeviceGroup) error {
+	return b.post(config, uriCm, uriDeviceGroup)
+}
+
+// DeleteDeviceGroup removes a device group.
+func (b *BigIP) DeleteDeviceGroup(name string) error {
+	return b.delete(uriCm, uriDeviceGroup, name)
+}
+
+// ModifyDeviceGroup allows you to change any attribute of a device group.
+// Fields that can be modified are referenced in the DeviceGroup struct. This
+// replaces the existing configuration, so use PatchDeviceGroup if you want
+// to change only particular attributes.
+func (b *BigIP) ModifyDeviceGroup(name string, config *DeviceGroup) error {
+	return b.put(config, uriCm, uriDeviceGroup, name)
+}
+
+// PatchDeviceGroup allows you to change any attribute of a device group.
+// This changes only the attributes provided, so use ModifyDeviceGroup if you
+// want to replace the existing configuration.
+func (b *BigIP) PatchDeviceGroup(name string, config *DeviceGroup) error {
+	return b.patch(config, uriCm, uriDeviceGroup, name)
+}
+
+// DeviceGroupDevices returns the member devices of a device group.
+func (b *BigIP) DeviceGroupDevices(group string) (*DeviceGroupDevices, error) {
+	var devices DeviceGroupDevices
+	err, _ := b.getForEntity(&devices, uriCm, uriDeviceGroup, group, uriDevices)
+	if err != nil {
+		return nil, err
+	}
+
+	return &devices, nil
+}
+
+// AddDeviceGroupDevice adds the device <device> to a device group.
+func (b *BigIP) AddDeviceGroupDevice(group, device string) error {
+	config := &DeviceGroupDevice{
+		Name: device,
+	}
+	return b.post(config, uriCm, uriDeviceGroup, group, uriDevices)
+}
+
+// DeleteDeviceGroupDevice removes the device <device> from a device group.
+func (b *BigIP) DeleteDeviceGroupDevice(group, device string) error {
+	return b.delete(uriCm, uriDeviceGroup, group, uriDevices, device)
+}
+
+// TrafficGroups returns a list of traffic groups.
+func (b *BigIP) TrafficGroups() (*TrafficGroups, error) {
+	var groups TrafficGroups
+	err, _ := b.getForEntity(&groups, uriCm, uriTrafficGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	return &groups, nil
+}
+
+// GetTrafficGroup retrieves a traffic group by name. Returns nil if the
+// traffic group does not exist.
+func (b *BigIP) GetTrafficGroup(name string) (*TrafficGroup, error) {
+	var group TrafficGroup
+	err, ok := b.getForEntity(&group, uriCm, uriTrafficGroup, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &group, nil
+}
+
+// CreateTrafficGroup adds a new traffic group that fails over to the given
+// devices in order.
+func (b *BigIP) CreateTrafficGroup(name string, haOrder ...string) error {
+	config := &TrafficGroup{
+		Name:    name,
+		HaOrder: haOrder,
+	}
+	if len(haOrder) > 0 {
+		config.FailoverMethod = TrafficGroupHAOrder
+	}
+
+	return b.post(config, uriCm, uriTrafficGroup)
+}
+
+// AddTrafficGroup adds a new traffic group by config to the BIG-IP system.
+func (b *BigIP) AddTrafficGroup(config *TrafficGroup) error {
+	return b.post(config, uriCm, uriTrafficGroup)
+}
+
+// DeleteTrafficGroup removes a traffic group.
+func (b *BigIP) DeleteTrafficGroup(name string) error {
+	return b.delete(uriCm, uriTrafficGroup, name)
+}
+
+// ModifyTrafficGroup allows you to change any attribute of a traffic group.
+// Fields that can be modified are referenced in the TrafficGroup struct. This
+// replaces the existing configuration, so use PatchTrafficGroup if you want
+// to change only particular attributes.
+func (b *BigIP) ModifyTrafficGroup(name string, config *TrafficGroup) error {
+	return b.put(config, uriCm, uriTrafficGroup, name)
+}
+
+// PatchTrafficGroup allows you to change any attribute of a traffic group.
+// This changes only the attributes provided, so use ModifyTrafficGroup if you
+// want to replace the existing configuration.
+func (b *BigIP) PatchTrafficGroup(name string, config *TrafficGroup) error {
+	return b.patch(config, uriCm, uriTrafficGroup, name)
+}
+
+// TrafficGroupStatus returns the failover state of every traffic group on
+// every device.
+func (b *BigIP) TrafficGroupStatus() ([]TrafficGroupStatus, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriCm, uriTrafficGroup, uriStats)
+	if err != nil {
+		return nil, err
+	}
+
+	var status []TrafficGroupStatus
+	for _, r := range stats.records() {
+		status = append(status, TrafficGroupStatus{
+			TrafficGroup:  r["trafficGroup"].Description,
+			Device:        r["deviceName"].Description,
+			FailoverState: FailoverState(r["failoverState"].Description),
+			NextActive:    r["nextActive"].Description == "true",
+		})
+	}
+
+	return status, nil
+}
+
+// ActiveTrafficGroups returns the device each traffic group is currently
+// active on, keyed by traffic group. Traffic groups that are not active on
+// any device are left out.
+func (b *BigIP) ActiveTrafficGroups() (map[string]string, error) {
+	status, err := b.TrafficGroupStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]string)
+	for _, s := range status {
+		if s.FailoverState == FailoverStateActive {
+			active[s.TrafficGroup] = s.Device
+		}
+	}
+
+	return active, nil
+}
+
+// SyncStatus returns the config sync status of the system and its device groups.
+func (b *BigIP) SyncStatus() (*SyncStatus, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriCm, uriSyncStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SyncStatus{}
+	for _, r := range stats.records() {
+		status.Color = r["color"].Description
+		status.Mode = r["mode"].Description
+		status.Status = r["status"].Description
+		status.Summary = r["summary"].Description
+		for k, e := range r {
+			if strings.HasSuffix(k, "/details") && e.NestedStats != nil {
+				for _, d := range e.NestedStats.records() {
+					status.Details = append(status.Details, d["details"].Description)
+				}
+			}
+		}
+	}
+
+	for _, d := range status.Details {
+		if m := syncGroupDetail.FindStringSubmatch(d); m != nil {
+			status.Groups = append(status.Groups, DeviceGroupSyncStatus{Name: m[1], Status: m[2], Message: m[3]})
+		} else if m := syncDeviceDetail.FindStringSubmatch(d); m != nil {
+			status.Devices = append(status.Devices, DeviceSyncStatus{Name: m[1], Status: m[2]})
+		}
+	}
+	for i := range status.Groups {
+		mentioned := messageWords(status.Groups[i].Message)
+		for _, d := range status.Devices {
+			if mentioned[d.Name] {
+				status.Groups[i].ConflictingDevices = append(status.Groups[i].ConflictingDevices, d.Name)
+			}
+		}
+	}
+
+	return status, nil
+}
+
+// messageWords returns the words of a sync status message, so device names
+// can be compared exactly: "bigip1" must not match "bigip10".
+func messageWords(message string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(message, func(r rune) bool {
+		return strings.ContainsRune(" \t,;:()'\"", r)
+	}) {
+		words[strings.TrimRight(w, ".")] = true
+	}
+	return words
+}
+
+// GetDeviceGroupSyncStatus returns the config sync status of a device group.
+// Returns nil if the sync status does not mention the device group.
+func (b *BigIP) GetDeviceGroupSyncStatus(group string) (*DeviceGroupSyncStatus, error) {
+	status, err := b.SyncStatus()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range status.Groups {
+		if path.Base(g.Name) == path.Base(group) {
+			return &g, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// ConfigSyncToGroupAndWait syncs this device's configuration to the device
+// group <group> and waits until the group is in sync. On failure or timeout
+// the last status is returned with the error, naming the conflicting devices.
+func (b *BigIP) ConfigSyncToGroupAndWait(group string, timeout time.Duration) (*DeviceGroupSyncStatus, error) {
+	deadline := time.Now().Add(timeout)
+	if err := b.ConfigSyncToGroup(group); err != nil {
+		return nil, err
+	}
+
+	var status *DeviceGroupSyncStatus
+	for time.Now().Before(deadline) {
+		var err error
+		status, err = b.GetDeviceGroupSyncStatus(group)
+		if err != nil {
+			return nil, err
+		}
+		if status != nil {
+			switch status.Status {
+			case syncStatusInSync:
+				return status, nil
+			case syncStatusSyncFailure:
+				return status, syncError(group, status)
+			}
+		}
+		time.Sleep(taskPollInterval)
+	}
+
+	if status == nil {
+		return nil, fmt.Errorf("Timed out after %s, no sync status for %s", timeout, group)
+	}
+	return status, fmt.Errorf("Timed out after %s: %s", timeout, syncError(group, status))
+}
+
+func syncError(group string, status *DeviceGroupSyncStatus) error {
+	if len(status.ConflictingDevices) > 0 {
+		return fmt.Errorf("%s is %s (%s): %s", group, status.Status, strings.Join(status.ConflictingDevices, ", "), status.Message)
+	}
+	return fmt.Errorf("%s is %s: %s", group, status.Status, status.Message)
+}
@@ -0,0 +1,509 @@
+package bigip
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CMTestSuite struct {
+	suite.Suite
+	Client          *BigIP
+	Server          *httptest.Server
+	LastRequest     *http.Request
+	LastRequestBody string
+	ResponseFunc    func(http.ResponseWriter, *http.Request)
+}
+
+func (s *CMTestSuite) SetupSuite() {
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.LastRequestBody = string(body)
+		s.LastRequest = r
+		if s.ResponseFunc != nil {
+			s.ResponseFunc(w, r)
+		}
+	}))
+
+	s.Client = NewSession(s.Server.URL, "", "", nil)
+}
+
+func (s *CMTestSuite) TearDownSuite() {
+	s.Server.Close()
+}
+
+func (s *CMTestSuite) SetupTest() {
+	s.ResponseFunc = nil
+	s.LastRequest = nil
+}
+
+func TestCMSuite(t *testing.T) {
+	suite.Run(t, new(CMTestSuite))
+}
+
+func (s *CMTestSuite) TestConfigSyncToGroup() {
+	err := s.Client.ConfigSyncToGroup("my-device-group")
+
+	s.Require().Nil(err, "Error syncing to group")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s", uriCm), s.LastRequest.URL.Path, "Wrong uri to sync")
+	s.Require().JSONEq(`{"command": "run", "utilCmdArgs": "config-sync to-group my-device-group"}`, s.LastRequestBody)
+}
+
+func (s *CMTestSuite) TestGenerateQkview() {
+	defer func(d time.Duration) { taskPollInterval = d }(taskPollInterval)
+	taskPollInterval = time.Millisecond
+	content := []byte("qkview content")
+	var requests []string
+	polls := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/mgmt/cm/autodeploy/qkview":
+			s.Require().JSONEq(`{"name": "support.qkview"}`, s.LastRequestBody)
+			w.Write([]byte(`{"name": "support.qkview", "id": "f4d2", "status": "IN_PROGRESS"}`))
+		case "/mgmt/cm/autodeploy/qkview/f4d2":
+			polls++
+			if polls == 1 {
+				w.Write([]byte(`{"name": "support.qkview", "id": "f4d2", "status": "IN_PROGRESS"}`))
+			} else {
+				w.Write([]byte(`{"name": "support.qkview", "id": "f4d2", "status": "SUCCEEDED"}`))
+			}
+		case "/mgmt/cm/autodeploy/qkview-download/support.qkview":
+			w.Header().Set("Content-Range", fmt.Sprintf("0-%d/%d", len(content)-1, len(content)))
+			w.Write(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	var buf bytes.Buffer
+	dl, err := s.Client.GenerateQkview(&buf, "support.qkview", true, 10*time.Second)
+
+	s.Require().Nil(err, "Error generating qkview")
+	s.Require().Equal(content, buf.Bytes())
+	s.Require().Equal(int64(len(content)), dl.TotalByteCount)
+	s.Require().Equal([]string{
+		"POST /mgmt/cm/autodeploy/qkview",
+		"GET /mgmt/cm/autodeploy/qkview/f4d2",
+		"GET /mgmt/cm/autodeploy/qkview/f4d2",
+		"GET /mgmt/cm/autodeploy/qkview-download/support.qkview",
+		"DELETE /mgmt/cm/autodeploy/qkview/f4d2",
+	}, requests)
+}
+
+func (s *CMTestSuite) TestGenerateQkviewFailed() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "support.qkview", "id": "f4d2", "status": "FAILED"}`))
+	}
+
+	_, err := s.Client.GenerateQkview(&bytes.Buffer{}, "support.qkview", false, 10*time.Second)
+
+	s.Require().Error(err)
+	s.Require().Equal("Qkview generation failed for support.qkview", err.Error())
+	s.Require().Equal("DELETE", s.LastRequest.Method)
+	s.Require().Equal("/mgmt/cm/autodeploy/qkview/f4d2", s.LastRequest.URL.Path)
+}
+
+func (s *CMTestSuite) TestGenerateQkviewDownloadFailed() {
+	content := []byte("qkview content")
+	var deleted bool
+	downloads := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "DELETE":
+			deleted = true
+		case r.URL.Path == "/mgmt/cm/autodeploy/qkview-download/support.qkview":
+			downloads++
+			if downloads == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("0-%d/%d", len(content)-1, len(content)))
+			w.Write(content)
+		default:
+			w.Write([]byte(`{"name": "support.qkview", "id": "f4d2", "status": "SUCCEEDED"}`))
+		}
+	}
+
+	var buf bytes.Buffer
+	dl, err := s.Client.GenerateQkview(&buf, "support.qkview", false, 10*time.Second)
+
+	s.Require().Error(err)
+	s.Require().False(deleted, "qkview deleted after a failed download")
+	s.Require().NotNil(dl, "No download to resume")
+
+	// The download is resumed at the offset of the failed one.
+	_, err = s.Client.DownloadQkview(&buf, "support.qkview", &DownloadOptions{Offset: dl.Offset})
+	s.Require().Nil(err, "Error resuming qkview download")
+	s.Require().Equal(content, buf.Bytes())
+
+	// With remove the qkview is deleted even if the download fails.
+	downloads = 0
+	_, err = s.Client.GenerateQkview(&bytes.Buffer{}, "support.qkview", true, 10*time.Second)
+	s.Require().Error(err)
+	s.Require().True(deleted, "qkview not deleted after a failed download with remove")
+}
+
+func (s *CMTestSuite) TestDevicesFailoverState() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": [
+  {"name": "bigip1", "failoverState": "active", "selfDevice": "true"},
+  {"name": "bigip2", "failoverState": "standby", "selfDevice": "false"}
+]}`))
+	}
+
+	device, err := s.Client.GetCurrentDevice()
+
+	s.Require().Nil(err, "Error getting current device")
+	s.Require().Equal("bigip1", device.Name)
+	s.Require().Equal(FailoverStateActive, device.FailoverState)
+	s.Require().True(device.FailoverState.Settled())
+	s.Require().False(FailoverStateOffline.Settled())
+}
+
+func (s *CMTestSuite) TestForceStandby() {
+	err := s.Client.ForceStandby("traffic-group-1")
+
+	s.Require().Nil(err, "Error forcing standby")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriSys, uriFailover), s.LastRequest.URL.Path, "Wrong uri to fail over")
+	s.Require().Equal("POST", s.LastRequest.Method)
+	s.Require().JSONEq(`{"command": "run", "standby": true, "trafficGroup": "traffic-group-1"}`, s.LastRequestBody)
+}
+
+func (s *CMTestSuite) TestForceOffline() {
+	err := s.Client.ForceOffline()
+
+	s.Require().Nil(err, "Error forcing offline")
+	s.Require().JSONEq(`{"command": "run", "offline": true}`, s.LastRequestBody)
+
+	err = s.Client.ReleaseOffline()
+
+	s.Require().Nil(err, "Error releasing offline")
+	s.Require().JSONEq(`{"command": "run", "online": true}`, s.LastRequestBody)
+}
+
+func (s *CMTestSuite) TestWaitForFailoverState() {
+	defer func(d time.Duration) { taskPollInterval = d }(taskPollInterval)
+	taskPollInterval = time.Millisecond
+	states := []string{"standby", "standby", "active"}
+	polls := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "bigip2", "failoverState": "` + states[polls] + `"}`))
+		polls++
+	}
+
+	err := s.Client.WaitForFailoverState("bigip2", FailoverStateActive, 10*time.Second)
+
+	s.Require().Nil(err, "Error waiting for peer to become active")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriCm, uriDevice, "bigip2"), s.LastRequest.URL.Path, "Wrong uri to fetch device")
+	s.Require().Equal(3, polls)
+}
+
+func (s *CMTestSuite) TestGetDeviceGroup() {
+	resp := `{
+  "kind": "tm:cm:device-group:device-groupstate",
+  "name": "failover-group",
+  "partition": "Common",
+  "fullPath": "/Common/failover-group",
+  "generation": 110,
+  "asmSync": "disabled",
+  "autoSync": "enabled",
+  "fullLoadOnSync": "false",
+  "incrementalConfigSyncSizeMax": 1024,
+  "networkFailover": "enabled",
+  "saveOnAutoSync": "false",
+  "type": "sync-failover"
+}`
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(resp))
+	}
+
+	group, err := s.Client.GetDeviceGroup("failover-group")
+
+	s.Require().Nil(err, "Error getting device group")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriCm, uriDeviceGroup, "failover-group"), s.LastRequest.URL.Path, "Wrong uri to fetch device group")
+	s.Require().Equal(DeviceGroupSyncFailover, group.Type)
+	s.Require().True(*group.AutoSync)
+	s.Require().False(*group.FullLoadOnSync)
+	s.Require().Equal(1024, group.IncrementalConfigSyncSizeMax)
+}
+
+func (s *CMTestSuite) TestCreateDeviceGroup() {
+	err := s.Client.CreateDeviceGroup("sync-group", DeviceGroupSyncOnly, "bigip1.example.com", "bigip2.example.com")
+
+	s.Require().Nil(err, "Error creating device group")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriCm, uriDeviceGroup), s.LastRequest.URL.Path, "Wrong uri to create device group")
+	s.Require().Equal("POST", s.LastRequest.Method)
+	s.Require().JSONEq(`{"name": "sync-group", "type": "sync-only", "devices": [{"name": "bigip1.example.com"}, {"name": "bigip2.example.com"}]}`, s.LastRequestBody)
+}
+
+func (s *CMTestSuite) TestPatchDeviceGroup() {
+	err := s.Client.PatchDeviceGroup("sync-group", &DeviceGroup{AutoSync: Bool(true), FullLoadOnSync: Bool(false)})
+
+	s.Require().Nil(err, "Error patching device group")
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"autoSync": "enabled", "fullLoadOnSync": "false"}`, s.LastRequestBody)
+}
+
+func (s *CMTestSuite) TestDeviceGroupDevices() {
+	err := s.Client.AddDeviceGroupDevice("sync-group", "bigip3.example.com")
+
+	s.Require().Nil(err, "Error adding device to device group")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s/%s", uriCm, uriDeviceGroup, "sync-group", uriDevices), s.LastRequest.URL.Path, "Wrong uri to add device")
+	s.Require().JSONEq(`{"name": "bigip3.example.com"}`, s.LastRequestBody)
+
+	err = s.Client.DeleteDeviceGroupDevice("sync-group", "bigip3.example.com")
+
+	s.Require().Nil(err, "Error removing device from device group")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s/%s/%s", uriCm, uriDeviceGroup, "sync-group", uriDevices, "bigip3.example.com"), s.LastRequest.URL.Path, "Wrong uri to remove device")
+	s.Require().Equal("DELETE", s.LastRequest.Method)
+}
+
+func syncStatusResponse(status, groupDetail string) string {
+	return `{
+  "kind": "tm:cm:sync-status:sync-statusstats",
+  "entries": {
+    "https://localhost/mgmt/tm/cm/sync-status/0": {
+      "nestedStats": {
+        "entries": {
+          "color": {"description": "green"},
+          "https://localhost/mgmt/tm/cm/syncStatus/0/details": {
+            "nestedStats": {
+              "entries": {
+                "https://localhost/mgmt/tm/cm/syncStatus/0/details/0": {"nestedStats": {"entries": {"details": {"description": "bigip1.example.com: connected (for 3400 seconds)"}}}},
+                "https://localhost/mgmt/tm/cm/syncStatus/0/details/1": {"nestedStats": {"entries": {"details": {"description": "bigip2.example.com: connected (for 3395 seconds)"}}}},
+                "https://localhost/mgmt/tm/cm/syncStatus/0/details/2": {"nestedStats": {"entries": {"details": {"description": "` + groupDetail + `"}}}}
+              }
+            }
+          },
+          "mode": {"description": "high-availability"},
+          "status": {"description": "` + status + `"},
+          "summary": {"description": "summary"}
+        }
+      }
+    }
+  }
+}`
+}
+
+func (s *CMTestSuite) TestSyncStatus() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(syncStatusResponse("Changes Pending", "failover-group (Changes Pending): There is a possible change conflict between bigip1.example.com and bigip2.example.com.")))
+	}
+
+	status, err := s.Client.SyncStatus()
+
+	s.Require().Nil(err, "Error getting sync status")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriCm, uriSyncStatus), s.LastRequest.URL.Path, "Wrong uri to fetch sync status")
+	s.Require().Equal("Changes Pending", status.Status)
+	s.Require().Equal("high-availability", status.Mode)
+	s.Require().Equal([]DeviceSyncStatus{
+		{Name: "bigip1.example.com", Status: "connected (for 3400 seconds)"},
+		{Name: "bigip2.example.com", Status: "connected (for 3395 seconds)"},
+	}, status.Devices)
+	s.Require().Equal([]DeviceGroupSyncStatus{{
+		Name:               "failover-group",
+		Status:             "Changes Pending",
+		Message:            "There is a possible change conflict between bigip1.example.com and bigip2.example.com.",
+		ConflictingDevices: []string{"bigip1.example.com", "bigip2.example.com"},
+	}}, status.Groups)
+}
+
+func (s *CMTestSuite) TestSyncStatusDeviceNamesMatchExactly() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(syncStatusResponse("Changes Pending", "failover-group (Changes Pending): Changes pending on bigip1.example.com.org.")))
+	}
+
+	status, err := s.Client.SyncStatus()
+
+	s.Require().Nil(err, "Error getting sync status")
+	s.Require().Len(status.Groups, 1)
+	s.Require().Empty(status.Groups[0].ConflictingDevices)
+}
+
+func (s *CMTestSuite) TestConfigSyncToGroupAndWait() {
+	defer func(d time.Duration) { taskPollInterval = d }(taskPollInterval)
+	taskPollInterval = time.Millisecond
+	polls := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			return
+		}
+		polls++
+		if polls < 3 {
+			w.Write([]byte(syncStatusResponse("Changes Pending", "failover-group (Changes Pending): Changes pending on bigip1.example.com")))
+			return
+		}
+		w.Write([]byte(syncStatusResponse("In Sync", "failover-group (In Sync): All devices in the device group are in sync")))
+	}
+
+	status, err := s.Client.ConfigSyncToGroupAndWait("/Common/failover-group", 10*time.Second)
+
+	s.Require().Nil(err, "Error syncing device group")
+	s.Require().Equal("In Sync", status.Status)
+	s.Require().Equal(3, polls)
+}
+
+func (s *CMTestSuite) TestConfigSyncToGroupAndWaitFailure() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(syncStatusResponse("Sync Failure", "failover-group (Sync Failure): A validation error occurred while syncing to bigip2.example.com")))
+	}
+
+	status, err := s.Client.ConfigSyncToGroupAndWait("failover-group", 10*time.Second)
+
+	s.Require().Error(err)
+	s.Require().Equal([]string{"bigip2.example.com"}, status.ConflictingDevices)
+	s.Require().Equal("failover-group is Sync Failure (bigip2.example.com): A validation error occurred while syncing to bigip2.example.com", err.Error())
+}
+
+func (s *CMTestSuite) TestTrustedDevices() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mgmt/tm/cm/trust-domain/Root":
+			w.Write([]byte(`{
+  "kind": "tm:cm:trust-domain:trust-domainstate",
+  "name": "Root",
+  "caDevices": ["/Common/bigip1.example.com", "/Common/bigip2.example.com"],
+  "status": "standalone",
+  "trustDevices": ["/Common/bigip1.example.com", "/Common/bigip2.example.com"],
+  "trustGroup": "/Common/device_trust_group"
+}`))
+		case "/mgmt/tm/cm/device":
+			w.Write([]byte(`{
+  "items": [
+    {"name": "bigip1.example.com", "fullPath": "/Common/bigip1.example.com", "managementIp": "10.0.0.1", "cert": "/Common/dtdi.crt", "key": "/Common/dtdi.key", "chassisId": "abc-1", "version": "13.1.0", "selfDevice": "true"},
+    {"name": "bigip2.example.com", "fullPath": "/Common/bigip2.example.com", "managementIp": "10.0.0.2", "cert": "/Common/dtdi.crt", "key": "/Common/dtdi.key", "chassisId": "abc-2", "version": "13.1.0", "selfDevice": "false"}
+  ]
+}`))
+		}
+	}
+
+	devices, err := s.Client.TrustedDevices()
+
+	s.Require().Nil(err, "Error getting trusted devices")
+	s.Require().Len(devices, 2)
+	s.Require().Equal("10.0.0.2", devices[1].ManagementIp)
+	s.Require().Equal("/Common/dtdi.crt", devices[1].Cert)
+	s.Require().Equal("abc-2", devices[1].ChassisId)
+	s.Require().True(devices[1].CertificateAuthority)
+}
+
+func (s *CMTestSuite) TestAddTrustedDevice() {
+	err := s.Client.AddTrustedDevice("10.0.0.2", "bigip2.example.com", "admin", "secret")
+
+	s.Require().Nil(err, "Error adding trusted device")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriCm, uriAddToTrust), s.LastRequest.URL.Path, "Wrong uri to add trusted device")
+	s.Require().Equal("POST", s.LastRequest.Method)
+	s.Require().JSONEq(`{"command": "run", "name": "Root", "caDevice": true, "device": "10.0.0.2", "deviceName": "bigip2.example.com", "username": "admin", "password": "secret"}`, s.LastRequestBody)
+}
+
+func (s *CMTestSuite) TestRemoveTrustedDevice() {
+	err := s.Client.RemoveTrustedDevice("bigip2.example.com")
+
+	s.Require().Nil(err, "Error removing trusted device")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriCm, uriRemoveFromTrust), s.LastRequest.URL.Path, "Wrong uri to remove trusted device")
+	s.Require().JSONEq(`{"command": "run", "name": "Root", "deviceName": "bigip2.example.com"}`, s.LastRequestBody)
+}
+
+func (s *CMTestSuite) TestResetTrust() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"command": "run", "commandResult": "\n` + bashExitMarker + `0"}`))
+	}
+
+	err := s.Client.ResetTrust()
+
+	s.Require().Nil(err, "Error resetting trust")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriUtil, uriBash), s.LastRequest.URL.Path)
+	s.Require().Contains(s.LastRequestBody, "delete cm trust-domain all")
+}
+
+func (s *CMTestSuite) TestGetTrafficGroup() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+  "kind": "tm:cm:traffic-group:traffic-groupstate",
+  "name": "traffic-group-1",
+  "partition": "Common",
+  "fullPath": "/Common/traffic-group-1",
+  "autoFailbackEnabled": "false",
+  "autoFailbackTime": 60,
+  "failoverMethod": "ha-order",
+  "haLoadFactor": 1,
+  "haOrder": ["/Common/bigip1.example.com", "/Common/bigip2.example.com"],
+  "isFloating": "true",
+  "mac": "02:01:d7:93:35:08",
+  "unitId": 1
+}`))
+	}
+
+	group, err := s.Client.GetTrafficGroup("traffic-group-1")
+
+	s.Require().Nil(err, "Error getting traffic group")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriCm, uriTrafficGroup, "traffic-group-1"), s.LastRequest.URL.Path, "Wrong uri to fetch traffic group")
+	s.Require().False(*group.AutoFailbackEnabled)
+	s.Require().True(*group.IsFloating)
+	s.Require().Equal(TrafficGroupHAOrder, group.FailoverMethod)
+	s.Require().Equal([]string{"/Common/bigip1.example.com", "/Common/bigip2.example.com"}, group.HaOrder)
+	s.Require().Equal("02:01:d7:93:35:08", group.Mac)
+}
+
+func (s *CMTestSuite) TestCreateTrafficGroup() {
+	err := s.Client.CreateTrafficGroup("traffic-group-2", "bigip2.example.com", "bigip1.example.com")
+
+	s.Require().Nil(err, "Error creating traffic group")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriCm, uriTrafficGroup), s.LastRequest.URL.Path, "Wrong uri to create traffic group")
+	s.Require().JSONEq(`{"name": "traffic-group-2", "failoverMethod": "ha-order", "haOrder": ["bigip2.example.com", "bigip1.example.com"]}`, s.LastRequestBody)
+}
+
+func (s *CMTestSuite) TestPatchTrafficGroup() {
+	err := s.Client.PatchTrafficGroup("traffic-group-2", &TrafficGroup{AutoFailbackEnabled: Bool(true), AutoFailbackTime: 30, HaLoadFactor: 2, Mac: "none"})
+
+	s.Require().Nil(err, "Error patching traffic group")
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"autoFailbackEnabled": "true", "autoFailbackTime": 30, "haLoadFactor": 2, "mac": "none"}`, s.LastRequestBody)
+}
+
+func (s *CMTestSuite) TestActiveTrafficGroups() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+  "kind": "tm:cm:traffic-group:traffic-groupstats",
+  "entries": {
+    "https://localhost/mgmt/tm/cm/traffic-group/~Common~traffic-group-1:~Common~bigip1.example.com/stats": {
+      "nestedStats": {"entries": {
+        "deviceName": {"description": "/Common/bigip1.example.com"},
+        "failoverState": {"description": "standby"},
+        "nextActive": {"description": "true"},
+        "trafficGroup": {"description": "/Common/traffic-group-1"}
+      }}
+    },
+    "https://localhost/mgmt/tm/cm/traffic-group/~Common~traffic-group-1:~Common~bigip2.example.com/stats": {
+      "nestedStats": {"entries": {
+        "deviceName": {"description": "/Common/bigip2.example.com"},
+        "failoverState": {"description": "active"},
+        "nextActive": {"description": "false"},
+        "trafficGroup": {"description": "/Common/traffic-group-1"}
+      }}
+    }
+  }
+}`))
+	}
+
+	status, err := s.Client.TrafficGroupStatus()
+
+	s.Require().Nil(err, "Error getting traffic group status")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriCm, uriTrafficGroup, uriStats), s.LastRequest.URL.Path, "Wrong uri to fetch traffic group status")
+	s.Require().Equal([]TrafficGroupStatus{
+		{TrafficGroup: "/Common/traffic-group-1", Device: "/Common/bigip1.example.com", FailoverState: FailoverStateStandby, NextActive: true},
+		{TrafficGroup: "/Common/traffic-group-1", Device: "/Common/bigip2.example.com", FailoverState: FailoverStateActive},
+	}, status)
+
+	active, err := s.Client.ActiveTrafficGroups()
+
+	s.Require().Nil(err, "Error getting active traffic groups")
+	s.Require().Equal(map[string]string{"/Common/traffic-group-1": "/Common/bigip2.example.com"}, active)
+}
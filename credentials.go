@@ -0,0 +1,262 @@
+package bigip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables read by NewEnvironmentCredentials.
+const (
+	DefaultUserEnv     = "BIGIP_USER"
+	DefaultPasswordEnv = "BIGIP_PASSWORD"
+	DefaultTokenEnv    = "BIGIP_TOKEN"
+)
+
+// Credentials are used to authenticate a session. If Token is set it is used
+// instead of logging in with User and Password.
+type Credentials struct {
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// CredentialProvider supplies the credentials of a session. A session
+// consults its provider on every login, when a request is rejected as
+// unauthorized and, without a token, for every request, so rotated
+// passwords are picked up without creating a new session. Providers have to
+// be safe for concurrent use.
+type CredentialProvider interface {
+	Credentials() (*Credentials, error)
+}
+
+// CredentialInvalidator is implemented by providers that cache credentials.
+// A session invalidates its provider when a request is rejected as
+// unauthorized, so the retry uses fresh credentials.
+type CredentialInvalidator interface {
+	Invalidate()
+}
+
+func (c *Credentials) validate() error {
+	if c.Token == "" && c.User == "" {
+		return errors.New("credentials contain neither a user nor a token")
+	}
+	return nil
+}
+
+// StaticCredentials always returns the same credentials.
+type StaticCredentials struct {
+	creds Credentials
+}
+
+// NewStaticCredentials returns a provider for a fixed user and password.
+func NewStaticCredentials(user, password string) *StaticCredentials {
+	return &StaticCredentials{Credentials{User: user, Password: password}}
+}
+
+// Credentials returns the static credentials.
+func (s *StaticCredentials) Credentials() (*Credentials, error) {
+	creds := s.creds
+	return &creds, nil
+}
+
+// EnvironmentCredentials reads the credentials from environment variables
+// each time they are needed. TokenVar is optional.
+type EnvironmentCredentials struct {
+	UserVar     string
+	PasswordVar string
+	TokenVar    string
+}
+
+// NewEnvironmentCredentials returns a provider reading BIGIP_USER,
+// BIGIP_PASSWORD and BIGIP_TOKEN.
+func NewEnvironmentCredentials() *EnvironmentCredentials {
+	return &EnvironmentCredentials{
+		UserVar:     DefaultUserEnv,
+		PasswordVar: DefaultPasswordEnv,
+		TokenVar:    DefaultTokenEnv,
+	}
+}
+
+// Credentials returns the credentials from the environment.
+func (e *EnvironmentCredentials) Credentials() (*Credentials, error) {
+	creds := &Credentials{
+		User:     os.Getenv(e.UserVar),
+		Password: os.Getenv(e.PasswordVar),
+	}
+	if e.TokenVar != "" {
+		creds.Token = os.Getenv(e.TokenVar)
+	}
+	if err := creds.validate(); err != nil {
+		return nil, fmt.Errorf("environment credentials: %s", err)
+	}
+
+	return creds, nil
+}
+
+// FileCredentials reads the credentials from a JSON file, i.e.:
+// {"user": "admin", "password": "secret"}. The file is read again whenever
+// its modification time changes.
+type FileCredentials struct {
+	Path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	creds   *Credentials
+}
+
+// NewFileCredentials returns a provider reading the JSON file at <path>.
+func NewFileCredentials(path string) *FileCredentials {
+	return &FileCredentials{Path: path}
+}
+
+// Credentials returns the credentials from the file, reloading it if it
+// changed since it was last read.
+func (f *FileCredentials) Credentials() (*Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	if f.creds == nil || !info.ModTime().Equal(f.modTime) {
+		data, err := ioutil.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		var creds Credentials
+		if err := json.Unmarshal(data, &creds); err != nil {
+			return nil, fmt.Errorf("%s: %s", f.Path, err)
+		}
+		if err := creds.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s", f.Path, err)
+		}
+		f.creds = &creds
+		f.modTime = info.ModTime()
+	}
+
+	creds := *f.creds
+	return &creds, nil
+}
+
+// Invalidate makes the next call read the file again.
+func (f *FileCredentials) Invalidate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.creds = nil
+}
+
+// CommandCredentials runs a helper program that prints the credentials as
+// JSON, in the same format as FileCredentials, to stdout. This allows
+// fetching credentials from a vault or issuing short-lived tokens.
+type CommandCredentials struct {
+	Name string
+	Args []string
+	// Timeout of the helper, no timeout if zero.
+	Timeout time.Duration
+	// CacheFor keeps using the last credentials for this long before running
+	// the helper again. Without it the helper runs for every request of a
+	// session without a token.
+	CacheFor time.Duration
+
+	mu      sync.Mutex
+	fetched time.Time
+	creds   *Credentials
+}
+
+// NewCommandCredentials returns a provider running the program <name> with
+// the given arguments.
+func NewCommandCredentials(name string, args ...string) *CommandCredentials {
+	return &CommandCredentials{Name: name, Args: args}
+}
+
+// Credentials runs the helper, unless cached credentials are still valid,
+// and returns the credentials it printed.
+func (c *CommandCredentials) Credentials() (*Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.creds == nil || time.Since(c.fetched) >= c.CacheFor {
+		ctx := context.Background()
+		if c.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+			defer cancel()
+		}
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s %s", c.Name, err, strings.TrimSpace(stderr.String()))
+		}
+		var creds Credentials
+		if err := json.Unmarshal(out, &creds); err != nil {
+			return nil, fmt.Errorf("%s: invalid output: %s", c.Name, err)
+		}
+		if err := creds.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s", c.Name, err)
+		}
+		c.creds = &creds
+		c.fetched = time.Now()
+	}
+
+	creds := *c.creds
+	return &creds, nil
+}
+
+// Invalidate makes the next call run the helper again, even if CacheFor has
+// not passed yet.
+func (c *CommandCredentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.creds = nil
+}
+
+// NewSessionWithCredentials sets up our connection to the BIG-IP system using
+// basic auth with the credentials of <provider>. If the provider returns a
+// token, it is sent instead.
+func NewSessionWithCredentials(host string, provider CredentialProvider, configOptions *ConfigOptions) *BigIP {
+	b := NewSession(host, "", "", configOptions)
+	b.Credentials = provider
+	return b
+}
+
+// NewTokenSessionWithCredentials sets up our connection to the BIG-IP system
+// using token authentication, see NewTokenSession. The provider is consulted
+// on every login, so a rotated password or a new token is used once the
+// token expires.
+func NewTokenSessionWithCredentials(host string, provider CredentialProvider, loginProviderName string, configOptions *ConfigOptions) (b *BigIP, err error) {
+	b = NewSessionWithCredentials(host, provider, configOptions)
+	b.loginProvider = loginProviderName
+	err = b.login()
+
+	return
+}
+
+// requestCredentials returns the credentials of a request, nil to use the
+// session's User and Password. Token sessions only use the provider to log
+// in, after which login stored the credentials.
+func (b *BigIP) requestCredentials() (*Credentials, error) {
+	if b.Credentials == nil || b.loginProvider != "" {
+		return nil, nil
+	}
+
+	return b.Credentials.Credentials()
+}
+
+// invalidateCredentials makes a caching provider fetch fresh credentials.
+func (b *BigIP) invalidateCredentials() {
+	if c, ok := b.Credentials.(CredentialInvalidator); ok {
+		c.Invalidate()
+	}
+}
@@ -0,0 +1,251 @@
+package bigip
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CredentialsTestSuite struct {
+	suite.Suite
+	Client          *BigIP
+	Server          *httptest.Server
+	LastRequest     *http.Request
+	LastRequestBody string
+	ResponseFunc    func(http.ResponseWriter, *http.Request)
+}
+
+func (s *CredentialsTestSuite) SetupSuite() {
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.LastRequestBody = string(body)
+		s.LastRequest = r
+		if s.ResponseFunc != nil {
+			s.ResponseFunc(w, r)
+		}
+	}))
+
+	s.Client = NewSession(s.Server.URL, "", "", nil)
+}
+
+func (s *CredentialsTestSuite) TearDownSuite() {
+	s.Server.Close()
+}
+
+func (s *CredentialsTestSuite) SetupTest() {
+	s.ResponseFunc = nil
+	s.LastRequest = nil
+}
+
+func TestCredentialsSuite(t *testing.T) {
+	suite.Run(t, new(CredentialsTestSuite))
+}
+
+// rotatingProvider returns the credentials it is given, counting the calls.
+type rotatingProvider struct {
+	creds Credentials
+	calls int
+}
+
+func (r *rotatingProvider) Credentials() (*Credentials, error) {
+	r.calls++
+	creds := r.creds
+	return &creds, nil
+}
+
+func (s *CredentialsTestSuite) TestStaticCredentials() {
+	creds, err := NewStaticCredentials("admin", "secret").Credentials()
+
+	s.Require().Nil(err, "Error getting static credentials")
+	s.Require().Equal(&Credentials{User: "admin", Password: "secret"}, creds)
+}
+
+func (s *CredentialsTestSuite) TestEnvironmentCredentials() {
+	p := NewEnvironmentCredentials()
+	os.Setenv(DefaultUserEnv, "")
+	os.Setenv(DefaultTokenEnv, "")
+	defer os.Unsetenv(DefaultUserEnv)
+	defer os.Unsetenv(DefaultPasswordEnv)
+	defer os.Unsetenv(DefaultTokenEnv)
+
+	_, err := p.Credentials()
+	s.Require().Error(err)
+
+	os.Setenv(DefaultUserEnv, "admin")
+	os.Setenv(DefaultPasswordEnv, "secret")
+	creds, err := p.Credentials()
+	s.Require().Nil(err, "Error getting environment credentials")
+	s.Require().Equal(&Credentials{User: "admin", Password: "secret"}, creds)
+
+	os.Setenv(DefaultPasswordEnv, "rotated")
+	creds, err = p.Credentials()
+	s.Require().Nil(err, "Error getting environment credentials")
+	s.Require().Equal("rotated", creds.Password)
+}
+
+func (s *CredentialsTestSuite) TestFileCredentials() {
+	dir, err := ioutil.TempDir("", "credentials")
+	s.Require().Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bigip.json")
+	s.Require().Nil(ioutil.WriteFile(path, []byte(`{"user": "admin", "password": "secret"}`), 0600))
+
+	p := NewFileCredentials(path)
+	creds, err := p.Credentials()
+	s.Require().Nil(err, "Error getting file credentials")
+	s.Require().Equal(&Credentials{User: "admin", Password: "secret"}, creds)
+
+	s.Require().Nil(ioutil.WriteFile(path, []byte(`{"user": "admin", "password": "rotated"}`), 0600))
+	later := time.Now().Add(time.Minute)
+	s.Require().Nil(os.Chtimes(path, later, later))
+	creds, err = p.Credentials()
+	s.Require().Nil(err, "Error getting file credentials")
+	s.Require().Equal("rotated", creds.Password)
+
+	s.Require().Nil(ioutil.WriteFile(path, []byte(`{"password": "secret"}`), 0600))
+	later = later.Add(time.Minute)
+	s.Require().Nil(os.Chtimes(path, later, later))
+	_, err = p.Credentials()
+	s.Require().Error(err)
+}
+
+func (s *CredentialsTestSuite) TestCommandCredentials() {
+	p := NewCommandCredentials("sh", "-c", `echo '{"token": "TOKEN1"}'`)
+	creds, err := p.Credentials()
+	s.Require().Nil(err, "Error getting command credentials")
+	s.Require().Equal(&Credentials{Token: "TOKEN1"}, creds)
+
+	p = NewCommandCredentials("sh", "-c", "echo denied >&2; exit 1")
+	_, err = p.Credentials()
+	s.Require().Error(err)
+	s.Require().Contains(err.Error(), "denied")
+}
+
+func (s *CredentialsTestSuite) TestTokenSessionWithCredentials() {
+	password := "secret"
+	token := ""
+	logins := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mgmt/shared/authn/login" {
+			var login struct {
+				Username string
+				Password string
+			}
+			json.Unmarshal([]byte(s.LastRequestBody), &login)
+			if login.Password != password {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			logins++
+			token = "TOKEN" + strconv.Itoa(logins)
+			w.Write([]byte(`{"token": {"token": "` + token + `", "expirationMicros": 1500000000000000}}`))
+			return
+		}
+		if r.Header.Get("X-F5-Auth-Token") != token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"items": []}`))
+	}
+
+	p := &rotatingProvider{creds: Credentials{User: "admin", Password: "secret"}}
+	b, err := NewTokenSessionWithCredentials(s.Server.URL, p, "tmos", nil)
+	s.Require().Nil(err, "Error creating token session")
+	s.Require().Equal("TOKEN1", b.Token)
+	s.Require().Equal(1, p.calls)
+
+	// The password is rotated and the token revoked; the next request logs
+	// in again with the new password.
+	password = "rotated"
+	p.creds.Password = "rotated"
+	token = "revoked"
+	_, err = b.Partitions()
+	s.Require().Nil(err, "Error after rotating the password")
+	s.Require().Equal(2, p.calls)
+	s.Require().Equal("TOKEN2", b.Token)
+	s.Require().Equal("rotated", b.Password)
+}
+
+func (s *CredentialsTestSuite) TestSessionWithCredentials() {
+	var user, password string
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ = r.BasicAuth()
+		w.Write([]byte(`{"items": []}`))
+	}
+
+	p := &rotatingProvider{creds: Credentials{User: "admin", Password: "secret"}}
+	b := NewSessionWithCredentials(s.Server.URL, p, nil)
+	_, err := b.Partitions()
+	s.Require().Nil(err, "Error with basic auth credentials")
+	s.Require().Equal("admin", user)
+	s.Require().Equal("secret", password)
+
+	p.creds.Password = "rotated"
+	_, err = b.Partitions()
+	s.Require().Nil(err, "Error with rotated credentials")
+	s.Require().Equal("rotated", password)
+}
+
+func (s *CredentialsTestSuite) TestSessionWithTokenOnlyProvider() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": []}`))
+	}
+
+	p := &rotatingProvider{creds: Credentials{Token: "ISSUED"}}
+	b := NewSessionWithCredentials(s.Server.URL, p, nil)
+	_, err := b.Partitions()
+
+	s.Require().Nil(err, "Error with token credentials")
+	s.Require().Equal("ISSUED", s.LastRequest.Header.Get("X-F5-Auth-Token"))
+	_, _, ok := s.LastRequest.BasicAuth()
+	s.Require().False(ok, "Basic auth sent with a token")
+}
+
+func (s *CredentialsTestSuite) TestSessionWithExternalToken() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": []}`))
+	}
+
+	p := &rotatingProvider{creds: Credentials{Token: "ISSUED"}}
+	b, err := NewTokenSessionWithCredentials(s.Server.URL, p, "tmos", nil)
+	s.Require().Nil(err, "Error creating token session")
+	_, err = b.Partitions()
+	s.Require().Nil(err, "Error with external token")
+	s.Require().Equal("ISSUED", s.LastRequest.Header.Get("X-F5-Auth-Token"))
+}
+
+func (s *CredentialsTestSuite) TestRetryInvalidatesCachedCredentials() {
+	dir, err := ioutil.TempDir("", "credentials")
+	s.Require().Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "password")
+	s.Require().Nil(ioutil.WriteFile(path, []byte("secret"), 0600))
+
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if _, password, _ := r.BasicAuth(); password != "rotated" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"items": []}`))
+	}
+
+	p := NewCommandCredentials("sh", "-c", `printf '{"user": "admin", "password": "%s"}' "$(cat "$0")"`, path)
+	p.CacheFor = time.Hour
+	b := NewSessionWithCredentials(s.Server.URL, p, nil)
+	_, err = b.Partitions()
+	s.Require().Error(err)
+
+	// The password is rotated; the cached credentials are dropped when the
+	// request is rejected.
+	s.Require().Nil(ioutil.WriteFile(path, []byte("rotated"), 0600))
+	_, err = b.Partitions()
+	s.Require().Nil(err, "Error after rotating the password")
+}
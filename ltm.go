@@ -543,6 +543,8 @@ type VirtualServer struct {
 	Profiles         []Profile  `json:"profiles,omitempty"`
 	Policies         []string   `json:"policies,omitempty"`
 	Metadata         []Metadata `json:"metadata,omitempty"`
+	// Only on 14.1 and later, replaces Destination and Source.
+	TrafficMatchingCriteria string `json:"trafficMatchingCriteria,omitempty"`
 }
 
 // Metadata are key/value pairs of arbitrary metadata
@@ -2137,10 +2139,60 @@ func (b *BigIP) DeletePolicy(name string) error {
 	return b.delete(uriLtm, uriPolicy, name, policyVersionSuffix)
 }
 
+// SavePolicy creates the policy or replaces an existing one. On systems with
+// draft/publish policies (12.1 and later) the policy is written to a draft
+// that is then published, earlier versions are changed in place.
+func (b *BigIP) SavePolicy(p *Policy) error {
+	drafts, err := b.Supports(CapabilityPolicyDrafts)
+	if err != nil {
+		return err
+	}
+	partition := p.Partition
+	if partition == "" {
+		partition = commonPartition
+	}
+	published := "/" + partition + "/" + p.Name
+
+	var existing Policy
+	err, exists := b.getForEntity(&existing, uriLtm, uriPolicy, published)
+	if err != nil {
+		return err
+	}
+	if !drafts {
+		if exists {
+			return b.UpdatePolicy(published, p)
+		}
+		return b.CreatePolicy(p)
+	}
+
+	draft := "/" + partition + "/Drafts/" + p.Name
+	d := *p
+	d.Name = draft
+	d.Partition = ""
+	d.FullPath = ""
+	normalizePolicy(&d)
+	if exists {
+		if err := b.CreateDraftFromPolicy(published); err != nil {
+			return err
+		}
+		err = b.put(&d, uriLtm, uriPolicy, draft)
+	} else {
+		err = b.post(&d, uriLtm, uriPolicy)
+	}
+	if err != nil {
+		return err
+	}
+
+	return b.PublishDraftPolicy(draft)
+}
+
 // CreateDraftFromPolicy called name. Name must be full name (ie ~partition~policyName).
 // The draft will be created with same name in same partition:
 // /partition/Drafts/PublishedPolicyName
 func (b *BigIP) CreateDraftFromPolicy(name string) error {
+	if err := b.requireCapability(CapabilityPolicyDrafts); err != nil {
+		return err
+	}
 	p := struct {
 	}{}
 	return b.patch(p, uriLtm, uriPolicy, name+"?options=create-draft")
@@ -2148,6 +2200,9 @@ func (b *BigIP) CreateDraftFromPolicy(name string) error {
 
 // PublishDraftPolicy. Name must be full path (ie /Partition/Drafts/name)
 func (b *BigIP) PublishDraftPolicy(name string) error {
+	if err := b.requireCapability(CapabilityPolicyDrafts); err != nil {
+		return err
+	}
 	p := struct {
 		Command string `json:"command"`
 		Name    string `json:"name"`
@@ -2171,3 +2226,82 @@ func (b *BigIP) ModifyPolicyRule(policyName, ruleName string, rule PolicyRule) e
 func (b *BigIP) RemoveRuleFromPolicy(ruleName, policyName string) error {
 	return b.delete(uriLtm, uriPolicy, policyName, uriRules, ruleName)
 }
+
+// TrafficMatchingCriterias contains a list of traffic matching criteria.
+type TrafficMatchingCriterias struct {
+	TrafficMatchingCriterias []TrafficMatchingCriteria `json:"items"`
+}
+
+// TrafficMatchingCriteria selects the traffic of a virtual server by address
+// and port lists, available on 14.1 and later.
+type TrafficMatchingCriteria struct {
+	Name                     string `json:"name,omitempty"`
+	Partition                string `json:"partition,omitempty"`
+	FullPath                 string `json:"fullPath,omitempty"`
+	Description              string `json:"description,omitempty"`
+	DestinationAddressInline string `json:"destinationAddressInline,omitempty"`
+	DestinationAddressList   string `json:"destinationAddressList,omitempty"`
+	DestinationPortInline    string `json:"destinationPortInline,omitempty"`
+	DestinationPortList      string `json:"destinationPortList,omitempty"`
+	Protocol                 string `json:"protocol,omitempty"`
+	RouteDomain              string `json:"routeDomain,omitempty"`
+	SourceAddressInline      string `json:"sourceAddressInline,omitempty"`
+	SourceAddressList        string `json:"sourceAddressList,omitempty"`
+	SourcePortInline         int    `json:"sourcePortInline,omitempty"`
+}
+
+// TrafficMatchingCriterias returns a list of traffic matching criteria.
+func (b *BigIP) TrafficMatchingCriterias() (*TrafficMatchingCriterias, error) {
+	if err := b.requireCapability(CapabilityTrafficMatchingCriteria); err != nil {
+		return nil, err
+	}
+	var criteria TrafficMatchingCriterias
+	err, _ := b.getForEntity(&criteria, uriLtm, uriTrafficMatching)
+	if err != nil {
+		return nil, err
+	}
+
+	return &criteria, nil
+}
+
+// GetTrafficMatchingCriteria returns the named traffic matching criteria, nil
+// if it does not exist.
+func (b *BigIP) GetTrafficMatchingCriteria(name string) (*TrafficMatchingCriteria, error) {
+	if err := b.requireCapability(CapabilityTrafficMatchingCriteria); err != nil {
+		return nil, err
+	}
+	var criteria TrafficMatchingCriteria
+	err, ok := b.getForEntity(&criteria, uriLtm, uriTrafficMatching, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &criteria, nil
+}
+
+// AddTrafficMatchingCriteria creates traffic matching criteria.
+func (b *BigIP) AddTrafficMatchingCriteria(config *TrafficMatchingCriteria) error {
+	if err := b.requireCapability(CapabilityTrafficMatchingCriteria); err != nil {
+		return err
+	}
+	return b.post(config, uriLtm, uriTrafficMatching)
+}
+
+// ModifyTrafficMatchingCriteria replaces the named traffic matching criteria.
+func (b *BigIP) ModifyTrafficMatchingCriteria(name string, config *TrafficMatchingCriteria) error {
+	if err := b.requireCapability(CapabilityTrafficMatchingCriteria); err != nil {
+		return err
+	}
+	return b.put(config, uriLtm, uriTrafficMatching, name)
+}
+
+// DeleteTrafficMatchingCriteria removes the named traffic matching criteria.
+func (b *BigIP) DeleteTrafficMatchingCriteria(name string) error {
+	if err := b.requireCapability(CapabilityTrafficMatchingCriteria); err != nil {
+		return err
+	}
+	return b.delete(uriLtm, uriTrafficMatching, name)
+}
@@ -2,8 +2,14 @@ package bigip
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -39,6 +45,7 @@ const (
 	uriUdp             = "udp"
 	uriVirtual         = "virtual"
 	uriVirtualAddress  = "virtual-address"
+	uriTrafficMatching = "traffic-matching-criteria"
 	uriGtm             = "gtm"
 	uriWideIp          = "wideip"
 	uriARecord         = "a"
@@ -54,7 +61,8 @@ const (
 	CONTEXT_CLIENT     = "clientside"
 	CONTEXT_ALL        = "all"
 
-	// Newer policy APIs have a draft-publish workflow that this library does not support.
+	// The policy functions use the 11.5.1 API that changes policies in place,
+	// SavePolicy uses the draft-publish workflow of newer versions instead.
 	policyVersionSuffix = "?ver=11.5.1"
 )
 
@@ -114,6 +122,17 @@ const (
 	uriRegistration = "registration"
 	uriFileTransfer = "file-transfer"
 	uriUploads      = "uploads"
+	uriDownloads    = "downloads"
+	uriUcsDownloads = "ucs-downloads"
+	uriMadm         = "madm"
+	uriBulk         = "bulk"
+	uriLicense      = "license"
+
+	// Directories on the BIG-IP system served by the file transfer endpoints.
+	downloadsDir = "/var/config/rest/downloads/"
+	ucsDir       = "/var/local/ucs/"
+	madmDir      = "/var/config/rest/madm/"
+	bulkDir      = "/var/config/rest/bulk/"
 
 	activationComplete   = "LICENSING_COMPLETE"
 	activationInProgress = "LICENSING_ACTIVATION_IN_PROGRESS"
@@ -130,6 +149,16 @@ type Activation struct {
 	LicenseText           *string  `json:"licenseText,omitempty"`
 	ErrorText             *string  `json:"errorText,omitempty"`
 	EulaText              *string  `json:"eulaText,omitempty"`
+	Dossier               *string  `json:"dossier,omitempty"`
+}
+
+// errorText returns the error of a failed activation, or its status if the
+// activation server didn't send one.
+func (a *Activation) errorText() string {
+	if a.ErrorText != nil {
+		return *a.ErrorText
+	}
+	return a.Status
 }
 
 // https://devcentral.f5.com/wiki/iControl.Licensing_resource_API.ashx
@@ -192,6 +221,334 @@ func (b *BigIP) GetLicenseState() (*LicenseState, error) {
 	return &l, nil
 }
 
+// License is a parsed view of the license state. Dates that are not set in
+// the license are zero.
+type License struct {
+	RegistrationKey  string
+	Usage            string
+	PlatformId       string
+	LicensedVersion  string
+	LicensedDate     time.Time
+	StartDate        time.Time
+	EndDate          time.Time
+	ServiceCheckDate time.Time
+	ActiveModules    []LicenseModule
+	OptionalModules  []LicenseModule
+	// Feature flags by name, i.e.: "perf_VE_throughput_Mbps": "10000".
+	FeatureFlags map[string]string
+}
+
+// LicenseModule is a licensed module, i.e. "Local Traffic Manager, VE-10G",
+// with the key and features that come with it.
+type LicenseModule struct {
+	Name     string
+	Key      string
+	Features []string
+}
+
+// licenseModuleNames maps modules to the names they appear under in the
+// modules of a license, i.e. "APM, Limited" or "DNS and GTM (250 QPS), VE".
+var licenseModuleNames = map[string][]string{
+	"ltm": {"Local Traffic Manager", "LTM"},
+	"gtm": {"Global Traffic Manager", "GTM", "DNS and GTM", "DNS Services", "DNS"},
+	"asm": {"Application Security Manager", "ASM"},
+	"apm": {"Access Policy Manager", "APM"},
+	"afm": {"Advanced Firewall Manager", "AFM"},
+}
+
+// licenseNameMatches reports whether <part>, a module name or feature of a
+// license, is <name>, optionally followed by a comma or a parenthesis, i.e.
+// "APM, Limited" is "APM" but "DNSSEC" is not "DNS".
+func licenseNameMatches(part, name string) bool {
+	part = strings.TrimSpace(part)
+	if !strings.HasPrefix(part, name) {
+		return false
+	}
+	rest := part[len(name):]
+	return rest == "" || strings.HasPrefix(rest, ",") || strings.HasPrefix(rest, " (")
+}
+
+// licensedModule reports whether the module <name> is enabled by the mod_
+// feature flags or is among the modules or their features. <name> is either
+// the short name of a module, i.e. "apm", one of its names in the license,
+// i.e. "Access Policy Manager", or a feature, i.e. "Rate Shaping".
+func licensedModule(modules []LicenseModule, flags map[string]string, name string) bool {
+	names := []string{name}
+	for short, aliases := range licenseModuleNames {
+		for _, alias := range aliases {
+			if alias == name {
+				name = short
+			}
+		}
+	}
+	if aliases, ok := licenseModuleNames[strings.ToLower(name)]; ok {
+		names = aliases
+	}
+	if flags["mod_"+strings.ToLower(name)] == "enabled" {
+		return true
+	}
+
+	for _, m := range modules {
+		// The key is checked too: entries without one, i.e. "Local Traffic
+		// Manager, VE|Application Security Manager, VE", have a module there.
+		parts := append([]string{m.Name, m.Key}, m.Features...)
+		for _, part := range parts {
+			for _, n := range names {
+				if licenseNameMatches(part, n) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// parseLicenseDate parses the dates of the license state.
+func parseLicenseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006/01/02", value)
+}
+
+// parseLicenseModule parses an entry of the active or optional modules. Active
+// modules are formatted as "name|key|feature|feature...".
+func parseLicenseModule(value string) LicenseModule {
+	parts := strings.Split(value, "|")
+	module := LicenseModule{Name: parts[0]}
+	if len(parts) > 1 {
+		module.Key = parts[1]
+	}
+	if len(parts) > 2 {
+		module.Features = parts[2:]
+	}
+	return module
+}
+
+// Parse returns a parsed view of the license state.
+func (l *LicenseState) Parse() (*License, error) {
+	license := &License{
+		RegistrationKey: l.RegistrationKey,
+		Usage:           l.Usage,
+		PlatformId:      l.PlatformId,
+		LicensedVersion: l.LicensedVersion,
+		FeatureFlags:    make(map[string]string),
+	}
+	dates := []struct {
+		value string
+		t     *time.Time
+	}{
+		{l.LicensedDateTime, &license.LicensedDate},
+		{l.LicenseStartDateTime, &license.StartDate},
+		{l.LicenseEndDateTime, &license.EndDate},
+		{l.ServiceCheckDateTime, &license.ServiceCheckDate},
+	}
+	for _, d := range dates {
+		t, err := parseLicenseDate(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid license date %q: %s", d.value, err)
+		}
+		*d.t = t
+	}
+	for _, m := range l.ActiveModules {
+		license.ActiveModules = append(license.ActiveModules, parseLicenseModule(m))
+	}
+	for _, m := range l.OptionalModules {
+		license.OptionalModules = append(license.OptionalModules, parseLicenseModule(m))
+	}
+	for _, f := range l.FeatureFlags {
+		license.FeatureFlags[f.FeatureName] = f.FeatureValue
+	}
+
+	return license, nil
+}
+
+// Feature returns the value of a feature flag and whether it is set.
+func (l *License) Feature(name string) (string, bool) {
+	value, ok := l.FeatureFlags[name]
+	return value, ok
+}
+
+// FeatureInt returns the value of a numeric feature flag, i.e.
+// "perf_VE_throughput_Mbps", and whether it is set.
+func (l *License) FeatureInt(name string) (int, bool) {
+	value, ok := l.FeatureFlags[name]
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+// ThroughputLimitMbps returns the licensed throughput of Virtual Edition, or
+// false if the throughput is not limited.
+func (l *License) ThroughputLimitMbps() (int, bool) {
+	return l.FeatureInt("perf_VE_throughput_Mbps")
+}
+
+// HasModule reports whether a module is licensed. <name> is either the short
+// name of a module, i.e. "afm", one of its names in the license, i.e.
+// "Advanced Firewall Manager", or a feature of an active module, i.e.
+// "Rate Shaping".
+func (l *License) HasModule(name string) bool {
+	return licensedModule(l.ActiveModules, l.FeatureFlags, name)
+}
+
+// ExpiresWithin reports whether the license ends within <d>. Licenses without
+// an end date never expire.
+func (l *License) ExpiresWithin(d time.Duration) bool {
+	return !l.EndDate.IsZero() && time.Now().Add(d).After(l.EndDate)
+}
+
+// ServiceCheckExpired reports whether the service check date is before
+// <date>. Software releases carry a license check date; the system only
+// upgrades to a release if the service check date is not before it, so
+// reactivate the license first.
+func (l *License) ServiceCheckExpired(date time.Time) bool {
+	return !l.ServiceCheckDate.IsZero() && l.ServiceCheckDate.Before(date)
+}
+
+// GetLicense returns a parsed view of the current license state.
+func (b *BigIP) GetLicense() (*License, error) {
+	state, err := b.GetLicenseState()
+	if err != nil {
+		return nil, err
+	}
+	return state.Parse()
+}
+
+// RevokeLicense revokes the license so its registration key can be used on
+// another system. The system must be able to reach the activation server.
+func (b *BigIP) RevokeLicense() error {
+	config := map[string]string{"command": "revoke"}
+	return b.post(config, uriSys, uriLicense)
+}
+
+// GetDossier starts a manual activation of the registration key and add-on
+// keys and returns the dossier of the system. Submit the dossier to the
+// activation server to obtain a license, i.e. from a system that can reach it.
+func (b *BigIP) GetDossier(regKey string, addOnKeys []string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	actreq := Activation{BaseRegKey: regKey, AddOnKeys: addOnKeys, IsAutomaticActivation: false}
+
+	if err := b.Activate(actreq); err != nil {
+		return "", err
+	}
+
+	for time.Now().Before(deadline) {
+		actresp, err := b.GetActivationStatus()
+		if err != nil {
+			return "", err
+		}
+
+		if actresp.Status == activationFailed {
+			return "", fmt.Errorf("Licensing failed: %s", actresp.errorText())
+		}
+		if actresp.Dossier != nil && *actresp.Dossier != "" {
+			return *actresp.Dossier, nil
+		}
+		time.Sleep(taskPollInterval)
+	}
+
+	return "", fmt.Errorf("Timed out after %s", timeout)
+}
+
+// ActivationClient obtains licenses for dossiers, i.e. by relaying them to
+// the activation server from a system with access to it. The Activation it
+// receives carries the keys, the dossier and, once accepted, the EULA; the
+// returned Activation carries the license or the EULA to accept.
+type ActivationClient interface {
+	Activate(a Activation) (*Activation, error)
+}
+
+// ActivationFunc adapts a function to an ActivationClient. Use it to supply a
+// license obtained out-of-band.
+type ActivationFunc func(a Activation) (*Activation, error)
+
+// Activate calls f(a).
+func (f ActivationFunc) Activate(a Activation) (*Activation, error) {
+	return f(a)
+}
+
+// HTTPActivationClient posts the Activation as JSON to URL and reads an
+// Activation from the reply. Any service implementing this, i.e. a local
+// stand-in for the activation server, can license air-gapped systems.
+type HTTPActivationClient struct {
+	URL    string
+	Client *http.Client
+}
+
+// Activate posts the activation to the activation service.
+func (c *HTTPActivationClient) Activate(a Activation) (*Activation, error) {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	body, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Post(c.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 400 {
+		return nil, fmt.Errorf("HTTP %d :: %s", res.StatusCode, string(data))
+	}
+
+	var actresp Activation
+	if err := json.Unmarshal(data, &actresp); err != nil {
+		return nil, err
+	}
+	return &actresp, nil
+}
+
+// ManualLicense licenses a system that can't reach the activation server. It
+// gets the dossier, obtains the license from <client> and installs it. If the
+// EULA has to be accepted, acceptEula is called with its text and the EULA is
+// accepted if it returns true; a nil acceptEula accepts it like AutoLicense.
+func (b *BigIP) ManualLicense(regKey string, addOnKeys []string, timeout time.Duration, client ActivationClient, acceptEula func(eula string) bool) error {
+	dossier, err := b.GetDossier(regKey, addOnKeys, timeout)
+	if err != nil {
+		return err
+	}
+
+	actreq := Activation{BaseRegKey: regKey, AddOnKeys: addOnKeys, IsAutomaticActivation: false, Dossier: &dossier}
+	actresp, err := client.Activate(actreq)
+	if err != nil {
+		return err
+	}
+	if actresp.LicenseText == nil && actresp.EulaText != nil {
+		if acceptEula != nil && !acceptEula(*actresp.EulaText) {
+			return errors.New("EULA was not accepted")
+		}
+		eula := *actresp.EulaText
+		actreq.EulaText = &eula
+		if actresp, err = client.Activate(actreq); err != nil {
+			return err
+		}
+	}
+	if actresp.ErrorText != nil {
+		return fmt.Errorf("Licensing failed: %s", actresp.errorText())
+	}
+	if actresp.LicenseText == nil {
+		return errors.New("Activation returned no license")
+	}
+
+	return b.InstallLicense(*actresp.LicenseText)
+}
+
 // Installs the given license.
 func (b *BigIP) InstallLicense(licenseText string) error {
 	r := map[string]string{"licenseText": licenseText}
@@ -224,7 +581,7 @@ loop:
 		case activationComplete:
 			return b.InstallLicense(*actresp.LicenseText)
 		case activationFailed:
-			return fmt.Errorf("Licensing failed: %s", *actresp.ErrorText)
+			return fmt.Errorf("Licensing failed: %s", actresp.errorText())
 		case activationNeedEula:
 			eula := *actresp.EulaText
 			actreq.EulaText = &eula
@@ -258,9 +615,9 @@ loop:
 		case activationComplete:
 			return b.InstallLicense(*actresp.LicenseText)
 		case activationNeedEula:
-			return fmt.Errorf("Tried to accept EULA, but status is: %s", *actresp.ErrorText)
+			return fmt.Errorf("Tried to accept EULA, but status is: %s", actresp.errorText())
 		case activationFailed:
-			return fmt.Errorf("Licensing failed: %s", *actresp.ErrorText)
+			return fmt.Errorf("Licensing failed: %s", actresp.errorText())
 		}
 		return fmt.Errorf("Unknown licensing status: %s", actresp.Status)
 	}
@@ -287,3 +644,36 @@ func (b *BigIP) UploadBytes(data []byte, filename string) (*Upload, error) {
 	size := int64(len(data))
 	return b.Upload(r, size, uriShared, uriFileTransfer, uriUploads, filename)
 }
+
+// Download a file from /var/config/rest/downloads, which is also where
+// UploadFile puts files. opts may be nil.
+func (b *BigIP) DownloadFile(w io.Writer, filename string, opts *DownloadOptions) (*Download, error) {
+	return b.downloadFrom(w, filename, downloadsDir, opts, uriShared, uriFileTransfer, uriDownloads, filename)
+}
+
+// Download a UCS archive from /var/local/ucs. opts may be nil.
+func (b *BigIP) DownloadUcsFile(w io.Writer, filename string, opts *DownloadOptions) (*Download, error) {
+	return b.downloadFrom(w, filename, ucsDir, opts, uriShared, uriFileTransfer, uriUcsDownloads, filename)
+}
+
+// Download a file from /var/config/rest/madm. opts may be nil.
+func (b *BigIP) DownloadMadmFile(w io.Writer, filename string, opts *DownloadOptions) (*Download, error) {
+	return b.downloadFrom(w, filename, madmDir, opts, uriShared, uriFileTransfer, uriMadm, filename)
+}
+
+// Download a file from /var/config/rest/bulk. opts may be nil.
+func (b *BigIP) DownloadBulkFile(w io.Writer, filename string, opts *DownloadOptions) (*Download, error) {
+	return b.downloadFrom(w, filename, bulkDir, opts, uriShared, uriFileTransfer, uriBulk, filename)
+}
+
+func (b *BigIP) downloadFrom(w io.Writer, filename, dir string, opts *DownloadOptions, path ...string) (*Download, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	if opts.Verify && opts.RemotePath == "" {
+		o := *opts
+		o.RemotePath = dir + filename
+		opts = &o
+	}
+	return b.Download(w, opts, path...)
+}
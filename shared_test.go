@@ -1,6 +1,9 @@
 package bigip
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +11,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"path"
+	"sort"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -425,3 +431,415 @@ func (s *SharedTestSuite) TestUploadBytes() {
 	s.Require().Equal(fmt.Sprintf("0-%d/%d", size-1, size), s.LastRequest.Header.Get("Content-Range"), "Wrong Content-Range header")
 	s.Require().Equal(fmt.Sprintf("/mgmt/shared/file-transfer/uploads/%s", filename), s.LastRequest.URL.Path, "Wrong uri to upload file")
 }
+
+// serveDownload answers chunked download requests for content the way the
+// BIG-IP does, rejecting ranges that reach past the end of the file.
+func serveDownload(content []byte) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start, end, _, err := parseContentRange(r.Header.Get("Content-Range"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		total := int64(len(content))
+		w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%d", start, end, total))
+		if end >= total {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write(content[start : end+1])
+	}
+}
+
+func (s *SharedTestSuite) TestDownloadFile() {
+	content := []byte("0123456789")
+	digest := sha256.Sum256(content)
+	var ranges []string
+	download := serveDownload(content)
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mgmt/tm/util/bash" {
+			w.Write([]byte(fmt.Sprintf(`{"commandResult": "10\n%s  /var/config/rest/downloads/test.txt\n\n%s0"}`, hex.EncodeToString(digest[:]), bashExitMarker)))
+			return
+		}
+		s.Require().Equal("/mgmt/shared/file-transfer/downloads/test.txt", r.URL.Path, "Wrong uri to download file")
+		ranges = append(ranges, r.Header.Get("Content-Range"))
+		download(w, r)
+	}
+
+	var buf bytes.Buffer
+	dl, err := s.Client.DownloadFile(&buf, "test.txt", &DownloadOptions{ChunkSize: 4, Verify: true})
+
+	s.Require().Nil(err, "Error downloading file")
+	s.Require().Equal(content, buf.Bytes())
+	s.Require().Equal([]string{"0-3/0", "4-7/10", "8-9/10"}, ranges)
+	s.Require().Equal(int64(10), dl.TotalByteCount)
+	s.Require().Equal(int64(10), dl.Offset)
+	s.Require().Equal(hex.EncodeToString(digest[:]), dl.SHA256)
+	s.Require().Contains(s.LastRequestBody, "sha256sum /var/config/rest/downloads/test.txt")
+}
+
+func (s *SharedTestSuite) TestDownloadResume() {
+	content := []byte("0123456789")
+	s.ResponseFunc = serveDownload(content)
+
+	var buf bytes.Buffer
+	buf.Write(content[:6])
+	h := sha256.New()
+	h.Write(content[:6])
+	dl, err := s.Client.DownloadUcsFile(&buf, "backup.ucs", &DownloadOptions{Offset: 6, Hash: h})
+
+	s.Require().Nil(err, "Error resuming download")
+	s.Require().Equal("/mgmt/shared/file-transfer/ucs-downloads/backup.ucs", s.LastRequest.URL.Path, "Wrong uri to download ucs")
+	s.Require().Equal(content, buf.Bytes())
+	digest := sha256.Sum256(content)
+	s.Require().Equal(hex.EncodeToString(digest[:]), dl.SHA256)
+}
+
+func (s *SharedTestSuite) TestDownloadChecksumMismatch() {
+	content := []byte("0123456789")
+	download := serveDownload(content)
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mgmt/tm/util/bash" {
+			w.Write([]byte(`{"commandResult": "10\nbadc0ffee  /var/config/rest/madm/f\n\n` + bashExitMarker + `0"}`))
+			return
+		}
+		download(w, r)
+	}
+
+	var buf bytes.Buffer
+	_, err := s.Client.DownloadMadmFile(&buf, "f", &DownloadOptions{Verify: true})
+
+	s.Require().Error(err)
+	s.Require().Contains(err.Error(), "checksum mismatch")
+}
+
+func (s *SharedTestSuite) TestDownloadInterrupted() {
+	content := []byte("0123456789")
+	download := serveDownload(content)
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Range") == "4-7/10" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		download(w, r)
+	}
+
+	var buf bytes.Buffer
+	dl, err := s.Client.DownloadBulkFile(&buf, "f", &DownloadOptions{ChunkSize: 4})
+
+	s.Require().Error(err)
+	s.Require().NotNil(dl, "Interrupted download should report progress")
+	s.Require().Equal(int64(4), dl.Offset)
+	s.Require().Equal("0123", buf.String())
+}
+
+// uploadServer collects chunks the way the BIG-IP does and reports the
+// upload status after each one.
+type uploadServer struct {
+	sync.Mutex
+	size       int64
+	data       []byte
+	used       map[string]int
+	ranges     []string
+	fail       map[string]int // number of times to reject a Content-Range
+	bashResult string         // commandResult returned for util/bash requests
+	bash       string         // last util/bash request body
+	server     *httptest.Server
+}
+
+func newUploadServer(size int64) *uploadServer {
+	return &uploadServer{size: size, data: make([]byte, size), used: map[string]int{}, fail: map[string]int{}}
+}
+
+// session starts a server for the upload. Chunks may arrive concurrently, so
+// the suite server, which records only the last request, is not used.
+func (u *uploadServer) session() *BigIP {
+	u.server = httptest.NewTLSServer(u)
+	return NewSession(u.server.URL, "", "", nil)
+}
+
+func (u *uploadServer) Close() {
+	u.server.Close()
+}
+
+func (u *uploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	if r.URL.Path == "/mgmt/tm/util/bash" {
+		u.bash = string(body)
+		w.Write([]byte(`{"commandResult": "` + u.bashResult + `"}`))
+		return
+	}
+	cr := r.Header.Get("Content-Range")
+	start, _, _, _ := parseContentRange(cr)
+
+	u.Lock()
+	defer u.Unlock()
+	u.ranges = append(u.ranges, cr)
+	if u.fail[cr] > 0 {
+		u.fail[cr]--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	copy(u.data[start:], body)
+	u.used[strconv.FormatInt(start, 10)] = len(body)
+	var received int64
+	for _, n := range u.used {
+		received += int64(n)
+	}
+	resp, _ := json.Marshal(Upload{
+		RemainingByteCount: u.size - received,
+		UsedChunks:         u.used,
+		TotalByteCount:     u.size,
+		LocalFilePath:      "/var/config/rest/downloads/test.txt",
+	})
+	w.Write(resp)
+}
+
+func (s *SharedTestSuite) TestUploadConcurrentChunks() {
+	content := []byte("0123456789abcdef")
+	u := newUploadServer(int64(len(content)))
+	client := u.session()
+	defer u.Close()
+
+	var progress []int64
+	upload, err := client.UploadWithOptions(bytes.NewReader(content), int64(len(content)), &UploadOptions{
+		ChunkSize:   4,
+		Concurrency: 3,
+		Progress:    func(sent, total int64) { progress = append(progress, sent) },
+	}, uriShared, uriFileTransfer, uriUploads, "test.txt")
+
+	s.Require().Nil(err, "Error uploading file")
+	s.Require().Equal(content, u.data)
+	sort.Strings(u.ranges)
+	s.Require().Equal([]string{"0-3/16", "12-15/16", "4-7/16", "8-11/16"}, u.ranges)
+	s.Require().Equal([]int64{4, 8, 12, 16}, progress)
+	s.Require().Equal(int64(0), upload.RemainingByteCount)
+	s.Require().Len(upload.UsedChunks, 4)
+}
+
+func (s *SharedTestSuite) TestUploadExactChunkMultiple() {
+	content := []byte("01234567")
+	u := newUploadServer(int64(len(content)))
+	client := u.session()
+	defer u.Close()
+
+	_, err := client.UploadWithOptions(bytes.NewReader(content), int64(len(content)), &UploadOptions{ChunkSize: 4}, uriShared, uriFileTransfer, uriUploads, "test.txt")
+
+	s.Require().Nil(err, "Error uploading file")
+	s.Require().Equal([]string{"0-3/8", "4-7/8"}, u.ranges)
+}
+
+func (s *SharedTestSuite) TestUploadRetry() {
+	defer func(d time.Duration) { uploadRetryDelay = d }(uploadRetryDelay)
+	uploadRetryDelay = time.Millisecond
+	content := []byte("01234567")
+	u := newUploadServer(int64(len(content)))
+	client := u.session()
+	defer u.Close()
+	u.fail["4-7/8"] = 2
+
+	_, err := client.UploadWithOptions(bytes.NewReader(content), int64(len(content)), &UploadOptions{ChunkSize: 4, Retries: 2}, uriShared, uriFileTransfer, uriUploads, "test.txt")
+
+	s.Require().Nil(err, "Error uploading file")
+	s.Require().Equal(content, u.data)
+	s.Require().Equal([]string{"0-3/8", "4-7/8", "4-7/8", "4-7/8"}, u.ranges)
+}
+
+func (s *SharedTestSuite) TestUploadResume() {
+	content := []byte("0123456789")
+	u := newUploadServer(int64(len(content)))
+	client := u.session()
+	defer u.Close()
+	u.fail["4-7/10"] = 1
+	opts := &UploadOptions{ChunkSize: 4}
+
+	upload, err := client.UploadWithOptions(bytes.NewReader(content), int64(len(content)), opts, uriShared, uriFileTransfer, uriUploads, "test.txt")
+	s.Require().Error(err)
+	s.Require().NotNil(upload, "Interrupted upload should report its status")
+	s.Require().Equal(map[string]int{"0": 4}, upload.UsedChunks)
+
+	u.ranges = nil
+	var progress []int64
+	opts.Resume = upload
+	opts.Progress = func(sent, total int64) { progress = append(progress, sent) }
+	upload, err = client.UploadWithOptions(bytes.NewReader(content), int64(len(content)), opts, uriShared, uriFileTransfer, uriUploads, "test.txt")
+
+	s.Require().Nil(err, "Error resuming upload")
+	s.Require().Equal([]string{"4-7/10", "8-9/10"}, u.ranges)
+	s.Require().Equal([]int64{4, 8, 10}, progress)
+	s.Require().Equal(content, u.data)
+	s.Require().Equal(int64(0), upload.RemainingByteCount)
+}
+
+func (s *SharedTestSuite) TestUploadVerify() {
+	content := []byte("verified content")
+	digest := sha256.Sum256(content)
+	u := newUploadServer(int64(len(content)))
+	u.bashResult = fmt.Sprintf(`%d\n%s  /var/config/rest/downloads/test.txt\n\n%s0`, len(content), hex.EncodeToString(digest[:]), bashExitMarker)
+	client := u.session()
+	defer u.Close()
+
+	_, err := client.UploadWithOptions(bytes.NewReader(content), int64(len(content)), &UploadOptions{Verify: true}, uriShared, uriFileTransfer, uriUploads, "test.txt")
+
+	s.Require().Nil(err, "Error verifying upload")
+	s.Require().Contains(u.bash, "sha256sum /var/config/rest/downloads/test.txt")
+}
+
+func (s *SharedTestSuite) TestGetLicense() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(validLicenseState))
+	}
+
+	license, err := s.Client.GetLicense()
+
+	s.Require().Nil(err, "Error getting license")
+	s.Require().Equal("reg key here", license.RegistrationKey)
+	s.Require().Equal(time.Date(2018, 5, 3, 7, 0, 0, 0, time.UTC), license.EndDate.UTC())
+	s.Require().Equal(time.Date(2018, 3, 29, 7, 0, 0, 0, time.UTC), license.ServiceCheckDate.UTC())
+	s.Require().Equal("Local Traffic Manager, VE-10G", license.ActiveModules[0].Name)
+	s.Require().Equal("RAYPVKR-EYOXDFQ", license.ActiveModules[0].Key)
+	s.Require().Contains(license.ActiveModules[0].Features, "Rate Shaping")
+	s.Require().Equal("Advanced Protocols, VE", license.OptionalModules[0].Name)
+
+	value, ok := license.Feature("perf_remote_crypto_client")
+	s.Require().True(ok)
+	s.Require().Equal("enabled", value)
+	mbps, ok := license.ThroughputLimitMbps()
+	s.Require().True(ok)
+	s.Require().Equal(10000, mbps)
+
+	s.Require().True(license.HasModule("ltm"))
+	s.Require().True(license.HasModule("Rate Shaping"))
+	s.Require().False(license.HasModule("afm"))
+	s.Require().True(license.HasModule("apm"), "APM, Limited not recognized")
+	s.Require().True(license.ExpiresWithin(0))
+	s.Require().True(license.ServiceCheckExpired(time.Date(2018, 4, 1, 0, 0, 0, 0, time.UTC)))
+	s.Require().False(license.ServiceCheckExpired(time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func (s *SharedTestSuite) TestLicenseHasModuleDNSSEC() {
+	license, err := (&LicenseState{ActiveModules: []string{"Local Traffic Manager, VE|KEY|DNSSEC"}}).Parse()
+	s.Require().Nil(err, "Error parsing license")
+
+	s.Require().True(license.HasModule("DNSSEC"))
+	s.Require().False(license.HasModule("DNS"), "DNSSEC licensed DNS")
+	s.Require().False(license.HasModule("gtm"), "DNSSEC licensed gtm")
+	s.Require().False(moduleLicensed(&LicenseState{ActiveModules: []string{"Local Traffic Manager, VE|KEY|DNSSEC"}}, "gtm"))
+}
+
+func (s *SharedTestSuite) TestParseLicenseWithoutDates() {
+	license, err := (&LicenseState{LicenseEndDateTime: "", ServiceCheckDateTime: "2030/01/31"}).Parse()
+
+	s.Require().Nil(err, "Error parsing license")
+	s.Require().False(license.ExpiresWithin(24 * time.Hour))
+	s.Require().Equal(time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC), license.ServiceCheckDate)
+
+	_, err = (&LicenseState{LicensedDateTime: "yesterday"}).Parse()
+	s.Require().Error(err)
+}
+
+func (s *SharedTestSuite) TestRevokeLicense() {
+	err := s.Client.RevokeLicense()
+
+	s.Require().Nil(err, "Error revoking license")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriSys, uriLicense), s.LastRequest.URL.Path, "Wrong uri to revoke license")
+	s.Require().Equal("POST", s.LastRequest.Method)
+	s.Require().JSONEq(`{"command": "revoke"}`, s.LastRequestBody)
+}
+
+func (s *SharedTestSuite) TestGetDossier() {
+	defer func(d time.Duration) { taskPollInterval = d }(taskPollInterval)
+	taskPollInterval = time.Millisecond
+	polls := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriShared, uriLicensing, uriActivation), r.URL.Path, "Wrong uri to get dossier")
+		if r.Method == "POST" {
+			s.Require().JSONEq(`{"baseRegKey": "ABCDE-FGHIJ", "addOnKeys": ["ADDON-1", "ADDON-2"], "isAutomaticActivation": false}`, s.LastRequestBody)
+			return
+		}
+		polls++
+		if polls == 1 {
+			w.Write([]byte(`{"status": "LICENSING_ACTIVATION_IN_PROGRESS"}`))
+			return
+		}
+		w.Write([]byte(`{"status": "LICENSING_DOSSIER_READY", "dossier": "0123456789abcdef"}`))
+	}
+
+	dossier, err := s.Client.GetDossier("ABCDE-FGHIJ", []string{"ADDON-1", "ADDON-2"}, time.Second)
+
+	s.Require().Nil(err, "Error getting dossier")
+	s.Require().Equal(2, polls)
+	s.Require().Equal("0123456789abcdef", dossier)
+}
+
+func (s *SharedTestSuite) TestGetDossierFailedWithoutErrorText() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "LICENSING_FAILED"}`))
+	}
+
+	_, err := s.Client.GetDossier("ABCDE-FGHIJ", nil, time.Second)
+
+	s.Require().Error(err)
+	s.Require().Equal("Licensing failed: LICENSING_FAILED", err.Error())
+}
+
+func (s *SharedTestSuite) TestAutoLicenseFailedWithoutErrorText() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "LICENSING_FAILED"}`))
+	}
+
+	err := s.Client.AutoLicense("ABCDE-FGHIJ", nil, time.Second)
+
+	s.Require().Error(err)
+	s.Require().Equal("Licensing failed: LICENSING_FAILED", err.Error())
+}
+
+func (s *SharedTestSuite) TestManualLicense() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriShared, uriLicensing, uriActivation) && r.Method == "GET" {
+			w.Write([]byte(`{"status": "LICENSING_DOSSIER_READY", "dossier": "dossier"}`))
+		}
+	}
+	var requests []string
+	activation := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, string(body))
+		var a Activation
+		json.Unmarshal(body, &a)
+		if a.EulaText == nil {
+			w.Write([]byte(`{"eulaText": "eula to accept"}`))
+			return
+		}
+		w.Write([]byte(`{"licenseText": "this is a new license"}`))
+	}))
+	defer activation.Close()
+
+	var eula string
+	err := s.Client.ManualLicense("ABCDE-FGHIJ", nil, time.Second, &HTTPActivationClient{URL: activation.URL}, func(text string) bool {
+		eula = text
+		return true
+	})
+
+	s.Require().Nil(err, "Error licensing manually")
+	s.Require().Equal("eula to accept", eula)
+	s.Require().Len(requests, 2)
+	s.Require().JSONEq(`{"baseRegKey": "ABCDE-FGHIJ", "isAutomaticActivation": false, "dossier": "dossier"}`, requests[0])
+	s.Require().JSONEq(`{"baseRegKey": "ABCDE-FGHIJ", "isAutomaticActivation": false, "dossier": "dossier", "eulaText": "eula to accept"}`, requests[1])
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriShared, uriLicensing, uriRegistration), s.LastRequest.URL.Path, "Wrong uri to install license")
+	s.Require().JSONEq(`{"licenseText": "this is a new license"}`, s.LastRequestBody)
+}
+
+func (s *SharedTestSuite) TestManualLicenseEulaDeclined() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "LICENSING_DOSSIER_READY", "dossier": "dossier"}`))
+	}
+	client := ActivationFunc(func(a Activation) (*Activation, error) {
+		eula := "eula to accept"
+		return &Activation{EulaText: &eula}, nil
+	})
+
+	err := s.Client.ManualLicense("ABCDE-FGHIJ", nil, time.Second, client, func(string) bool { return false })
+
+	s.Require().Error(err)
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriShared, uriLicensing, uriActivation), s.LastRequest.URL.Path, "License should not be installed")
+}
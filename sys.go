@@ -2,7 +2,18 @@ package bigip
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -11,22 +22,78 @@ const (
 	uriSyslog         = "syslog"
 	uriSoftware       = "software"
 	uriVolume         = "volume"
+	uriImage          = "image"
+	uriHotfix         = "hotfix"
 	uriHardware       = "hardware"
 	uriGlobalSettings = "global-settings"
 	uriManagementIp   = "management-ip"
+	uriMgmtRoute      = "management-route"
+	uriNtp            = "ntp"
+	uriDns            = "dns"
 	uriFile           = "file"
 	uriSslCert        = "ssl-cert"
 	uriSslKey         = "ssl-key"
 	//uriPlatform = "?$select=platform"
-	uriConfig = "config"
+	uriConfig    = "config"
+	uriUcs       = "ucs"
+	uriReady     = "ready"
+	uriMcpState  = "mcp-state"
+	uriEcho      = "echo"
+	uriProvision = "provision"
+	uriHostInfo  = "host-info"
+	uriDb        = "db"
+	uriVersion   = "version"
+	uriDisk      = "disk"
+	uriLogical   = "logical-disk"
+	uriCluster   = "cluster"
+	uriMembers   = "members"
+	uriPerf      = "performance"
+	uriAllStats  = "all-stats"
+	uriTmmInfo   = "tmm-info"
+	uriTraffic   = "tmm-traffic"
+	uriCpu       = "cpu"
+	uriMemory    = "memory"
+	uriSnmp      = "snmp"
+	uriCommunity = "communities"
+	uriSnmpUser  = "users"
+	uriTrap      = "traps"
 )
 
+// readyPollInterval is how often WaitUntilReady checks the system.
+var readyPollInterval = 5 * time.Second
+
+// provisionRestartGrace is how long SetProvisioning waits for the system to
+// start restarting its services. Changes that need no restart never leave
+// the ready state.
+var provisionRestartGrace = time.Minute
+
+// Provisioning levels
+const (
+	ProvisionNone      = "none"
+	ProvisionMinimum   = "minimum"
+	ProvisionNominal   = "nominal"
+	ProvisionDedicated = "dedicated"
+)
+
+// provisionLevels are the levels a module can be provisioned at.
+var provisionLevels = map[string]bool{
+	ProvisionNone:      true,
+	ProvisionMinimum:   true,
+	ProvisionNominal:   true,
+	ProvisionDedicated: true,
+}
+
+// provisionMemoryPerModule is the memory needed for each provisioned module,
+// following the sizing guidance for Virtual Edition: 4 GB for LTM alone, 8 GB
+// for two modules and so on.
+const provisionMemoryPerModule = 4 << 30
+
 type Volumes struct {
 	Volumes []Volume `json:"items,omitempty"`
 }
 
 type Volume struct {
-	Name       string `json:"items,omitempty"`
+	Name       string `json:"name,omitempty"`
 	FullPath   string `json:"fullPath,omitempty"`
 	Generation int    `json:"generation,omitempty"`
 	SelfLink   string `json:"selfLink,omitempty"`
@@ -49,15 +116,251 @@ func (b *BigIP) Volumes() (*Volumes, error) {
 	return &volumes, nil
 }
 
+// GetVolume retrieves a software volume by name, i.e.: "HD1.2". Returns nil
+// if the volume does not exist.
+func (b *BigIP) GetVolume(name string) (*Volume, error) {
+	var volume Volume
+	err, ok := b.getForEntity(&volume, uriSys, uriSoftware, uriVolume, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &volume, nil
+}
+
+// DeleteVolume removes a software volume. The active volume cannot be removed.
+func (b *BigIP) DeleteVolume(name string) error {
+	return b.delete(uriSys, uriSoftware, uriVolume, name)
+}
+
+// InstallComplete reports whether an installation to the volume finished.
+func (v *Volume) InstallComplete() bool {
+	return v.Status == "complete"
+}
+
+// InstallFailed reports whether an installation to the volume failed.
+func (v *Volume) InstallFailed() bool {
+	return strings.HasPrefix(v.Status, "failed")
+}
+
+// InstallPercent returns the progress of an installation to the volume,
+// which the BIG-IP reports in the status as i.e.: "installing 10.000 pct".
+func (v *Volume) InstallPercent() float64 {
+	if v.InstallComplete() {
+		return 100
+	}
+	fields := strings.Fields(v.Status)
+	if len(fields) == 3 && fields[0] == "installing" && fields[2] == "pct" {
+		pct, _ := strconv.ParseFloat(fields[1], 64)
+		return pct
+	}
+	return 0
+}
+
+// WaitForVolumeInstall waits until the installation to the volume completes
+// or fails. progress, if set, is called with the volume each time it is checked.
+func (b *BigIP) WaitForVolumeInstall(name string, timeout time.Duration, progress func(*Volume)) error {
+	return b.waitForVolumeInstall(name, "", timeout, progress)
+}
+
+// waitForVolumeInstall waits for an installation like WaitForVolumeInstall. If
+// <version> is set, a complete volume must also run that version, so a volume
+// that still reports an earlier installation is not mistaken for the new one.
+func (b *BigIP) waitForVolumeInstall(name, version string, timeout time.Duration, progress func(*Volume)) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		volume, err := b.GetVolume(name)
+		if err != nil {
+			return err
+		}
+		if volume != nil {
+			if progress != nil {
+				progress(volume)
+			}
+			if volume.InstallComplete() && (version == "" || volume.Version == version) {
+				return nil
+			}
+			if volume.InstallFailed() {
+				return fmt.Errorf("Installation to %s failed: %s", name, volume.Status)
+			}
+		}
+		time.Sleep(taskPollInterval)
+	}
+
+	return fmt.Errorf("Timed out after %s", timeout)
+}
+
+// SetBootVolume makes <name> the volume the BIG-IP system boots from next.
+func (b *BigIP) SetBootVolume(name string) error {
+	result, err := b.RunBashArgs("switchboot", "-b", name)
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("Unable to set boot volume %s: %s", name, strings.TrimSpace(result.Output))
+	}
+
+	return nil
+}
+
+// SoftwareImages contains a list of software images or hotfixes.
+type SoftwareImages struct {
+	SoftwareImages []SoftwareImage `json:"items,omitempty"`
+}
+
+// SoftwareImage contains information about a software image or hotfix in
+// /shared/images.
+type SoftwareImage struct {
+	Name         string `json:"name,omitempty"`
+	FullPath     string `json:"fullPath,omitempty"`
+	Generation   int    `json:"generation,omitempty"`
+	Build        string `json:"build,omitempty"`
+	BuildDate    string `json:"buildDate,omitempty"`
+	Checksum     string `json:"checksum,omitempty"`
+	FileSize     string `json:"fileSize,omitempty"`
+	ID           string `json:"id,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Product      string `json:"product,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Verified     string `json:"verified,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+// SoftwareInstall is used to install a software image or hotfix to a volume.
+type SoftwareInstall struct {
+	Command string                   `json:"command"`
+	Name    string                   `json:"name"`
+	Volume  string                   `json:"volume"`
+	Options []map[string]interface{} `json:"options,omitempty"`
+}
+
+// SoftwareImages returns a list of software images.
+func (b *BigIP) SoftwareImages() (*SoftwareImages, error) {
+	var images SoftwareImages
+	err, _ := b.getForEntity(&images, uriSys, uriSoftware, uriImage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &images, nil
+}
+
+// GetSoftwareImage retrieves a software image by name. Returns nil if the
+// image does not exist.
+func (b *BigIP) GetSoftwareImage(name string) (*SoftwareImage, error) {
+	var image SoftwareImage
+	err, ok := b.getForEntity(&image, uriSys, uriSoftware, uriImage, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &image, nil
+}
+
+// DeleteSoftwareImage removes a software image from /shared/images.
+func (b *BigIP) DeleteSoftwareImage(name string) error {
+	return b.delete(uriSys, uriSoftware, uriImage, name)
+}
+
+// Hotfixes returns a list of hotfixes.
+func (b *BigIP) Hotfixes() (*SoftwareImages, error) {
+	var hotfixes SoftwareImages
+	err, _ := b.getForEntity(&hotfixes, uriSys, uriSoftware, uriHotfix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &hotfixes, nil
+}
+
+// InstallSoftwareImage starts installing the image <name> to <volume>, i.e.:
+// "HD1.2". Set createVolume if the volume does not exist yet. Use
+// WaitForVolumeInstall to follow the installation.
+func (b *BigIP) InstallSoftwareImage(name, volume string, createVolume bool) error {
+	config := &SoftwareInstall{
+		Command: "install",
+		Name:    name,
+		Volume:  volume,
+	}
+	if createVolume {
+		config.Options = append(config.Options, map[string]interface{}{"create-volume": ""})
+	}
+
+	return b.post(config, uriSys, uriSoftware, uriImage)
+}
+
+// InstallHotfix starts installing the hotfix <name> to <volume>. The base
+// image of the hotfix must be available in /shared/images.
+func (b *BigIP) InstallHotfix(name, volume string, createVolume bool) error {
+	config := &SoftwareInstall{
+		Command: "install",
+		Name:    name,
+		Volume:  volume,
+	}
+	if createVolume {
+		config.Options = append(config.Options, map[string]interface{}{"create-volume": ""})
+	}
+
+	return b.post(config, uriSys, uriSoftware, uriHotfix)
+}
+
+// UpgradeSoftware uploads the image in <f>, installs it to <volume> and sets
+// that volume as the boot location. The system runs the new software after
+// the next reboot. progress, if set, is called while the image is installed.
+func (b *BigIP) UpgradeSoftware(f *os.File, volume string, timeout time.Duration, progress func(*Volume)) error {
+	deadline := time.Now().Add(timeout)
+	if _, err := b.UploadSoftwareImage(f); err != nil {
+		return err
+	}
+
+	// The image is listed once the BIG-IP has finished checking it.
+	name := path.Base(f.Name())
+	var image *SoftwareImage
+	for {
+		var err error
+		image, err = b.GetSoftwareImage(name)
+		if err != nil {
+			return err
+		}
+		if image != nil {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("Timed out after %s", timeout)
+		}
+		time.Sleep(taskPollInterval)
+	}
+
+	existing, err := b.GetVolume(volume)
+	if err != nil {
+		return err
+	}
+	if err := b.InstallSoftwareImage(name, volume, existing == nil); err != nil {
+		return err
+	}
+	if err := b.waitForVolumeInstall(volume, image.Version, time.Until(deadline), progress); err != nil {
+		return err
+	}
+
+	return b.SetBootVolume(volume)
+}
+
 type ManagementIP struct {
-	Addresses []ManagementIPAddress
+	Addresses []ManagementIPAddress `json:"items"`
 }
 
 type ManagementIPAddress struct {
-	Name       string `json:"items,omitempty"`
-	FullPath   string `json:"fullPath,omitempty"`
-	Generation int    `json:"generation,omitempty"`
-	SelfLink   string `json:"selfLink,omitempty"`
+	Name        string `json:"name,omitempty"`
+	FullPath    string `json:"fullPath,omitempty"`
+	Generation  int    `json:"generation,omitempty"`
+	SelfLink    string `json:"selfLink,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 func (b *BigIP) ManagementIPs() (*ManagementIP, error) {
@@ -70,6 +373,21 @@ func (b *BigIP) ManagementIPs() (*ManagementIP, error) {
 	return &managementIP, nil
 }
 
+// CreateManagementIP adds the management address <address> in CIDR
+// notation, i.e.: "192.168.1.245/24".
+func (b *BigIP) CreateManagementIP(address string) error {
+	config := &ManagementIPAddress{
+		Name: address,
+	}
+	return b.post(config, uriSys, uriManagementIp)
+}
+
+// DeleteManagementIP removes the management address <address>. Removing the
+// address the session connects to ends the session.
+func (b *BigIP) DeleteManagementIP(address string) error {
+	return b.delete(uriSys, uriManagementIp, address)
+}
+
 type SyslogRemoteServer struct {
 	Name       string `json:"name,omitempty"`
 	Host       string `json:"host,omitempty"`
@@ -97,6 +415,217 @@ func (b *BigIP) SetSyslog(config Syslog) error {
 	return b.put(config, uriSys, uriSyslog)
 }
 
+// NTP contains the NTP configuration.
+type NTP struct {
+	SelfLink string   `json:"selfLink,omitempty"`
+	Servers  []string `json:"servers,omitempty"`
+	// Olson time zone, i.e.: "America/Los_Angeles".
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// NTP returns the NTP configuration.
+func (b *BigIP) NTP() (*NTP, error) {
+	var ntp NTP
+	err, _ := b.getForEntity(&ntp, uriSys, uriNtp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ntp, nil
+}
+
+// SetNTP changes the attributes provided of the NTP configuration. Servers
+// is left unchanged if nil and cleared if empty.
+func (b *BigIP) SetNTP(config NTP) error {
+	body := struct {
+		NTP
+		Servers *[]string `json:"servers,omitempty"`
+	}{NTP: config}
+	if config.Servers != nil {
+		body.Servers = &config.Servers
+	}
+
+	return b.patch(body, uriSys, uriNtp)
+}
+
+// DNS contains the DNS resolver configuration.
+type DNS struct {
+	SelfLink     string   `json:"selfLink,omitempty"`
+	NameServers  []string `json:"nameServers,omitempty"`
+	NumberOfDots int      `json:"numberOfDots,omitempty"`
+	Search       []string `json:"search,omitempty"`
+}
+
+// DNS returns the DNS resolver configuration.
+func (b *BigIP) DNS() (*DNS, error) {
+	var dns DNS
+	err, _ := b.getForEntity(&dns, uriSys, uriDns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dns, nil
+}
+
+// SetDNS changes the attributes provided of the DNS resolver configuration.
+// NameServers and Search are left unchanged if nil and cleared if empty.
+func (b *BigIP) SetDNS(config DNS) error {
+	body := struct {
+		DNS
+		NameServers *[]string `json:"nameServers,omitempty"`
+		Search      *[]string `json:"search,omitempty"`
+	}{DNS: config}
+	if config.NameServers != nil {
+		body.NameServers = &config.NameServers
+	}
+	if config.Search != nil {
+		body.Search = &config.Search
+	}
+
+	return b.patch(body, uriSys, uriDns)
+}
+
+type globalSettingsDTO struct {
+	SelfLink                 string `json:"selfLink,omitempty"`
+	ConsoleInactivityTimeout int    `json:"consoleInactivityTimeout,omitempty"`
+	GuiAudit                 string `json:"guiAudit,omitempty" bool:"enabled"`
+	GuiSecurityBanner        string `json:"guiSecurityBanner,omitempty" bool:"enabled"`
+	GuiSecurityBannerText    string `json:"guiSecurityBannerText,omitempty"`
+	GuiSetup                 string `json:"guiSetup,omitempty" bool:"enabled"`
+	Hostname                 string `json:"hostname,omitempty"`
+	MgmtDhcp                 string `json:"mgmtDhcp,omitempty" bool:"enabled"`
+}
+
+// GlobalSettings contains the system wide settings. Disable GuiSetup to skip
+// the setup utility on a device configured from code.
+type GlobalSettings struct {
+	SelfLink                 string
+	ConsoleInactivityTimeout int
+	GuiAudit                 *bool
+	GuiSecurityBanner        *bool
+	GuiSecurityBannerText    string
+	GuiSetup                 *bool
+	Hostname                 string
+	MgmtDhcp                 *bool
+}
+
+func (g *GlobalSettings) MarshalJSON() ([]byte, error) {
+	var dto globalSettingsDTO
+	marshal(&dto, g)
+	return json.Marshal(dto)
+}
+
+func (g *GlobalSettings) UnmarshalJSON(b []byte) error {
+	var dto globalSettingsDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(g, &dto)
+}
+
+// GlobalSettings returns the system wide settings.
+func (b *BigIP) GlobalSettings() (*GlobalSettings, error) {
+	var settings GlobalSettings
+	err, _ := b.getForEntity(&settings, uriSys, uriGlobalSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// SetGlobalSettings changes the attributes provided of the system wide
+// settings.
+func (b *BigIP) SetGlobalSettings(config *GlobalSettings) error {
+	return b.patch(config, uriSys, uriGlobalSettings)
+}
+
+// SetHostname changes the host name of the system.
+func (b *BigIP) SetHostname(hostname string) error {
+	return b.SetGlobalSettings(&GlobalSettings{Hostname: hostname})
+}
+
+// ManagementRoutes contains a list of every management route.
+type ManagementRoutes struct {
+	ManagementRoutes []ManagementRoute `json:"items"`
+}
+
+// ManagementRoute contains information about each individual management
+// route. You can use all of these fields when modifying a management route.
+type ManagementRoute struct {
+	Name        string `json:"name,omitempty"`
+	Partition   string `json:"partition,omitempty"`
+	FullPath    string `json:"fullPath,omitempty"`
+	Generation  int    `json:"generation,omitempty"`
+	Description string `json:"description,omitempty"`
+	Gateway     string `json:"gateway,omitempty"`
+	Mtu         int    `json:"mtu,omitempty"`
+	// Destination in CIDR notation, or "default".
+	Network string `json:"network,omitempty"`
+}
+
+// ManagementRoutes returns a list of management routes.
+func (b *BigIP) ManagementRoutes() (*ManagementRoutes, error) {
+	var routes ManagementRoutes
+	err, _ := b.getForEntity(&routes, uriSys, uriMgmtRoute)
+	if err != nil {
+		return nil, err
+	}
+
+	return &routes, nil
+}
+
+// GetManagementRoute retrieves a management route by name. Returns nil if
+// the management route does not exist.
+func (b *BigIP) GetManagementRoute(name string) (*ManagementRoute, error) {
+	var route ManagementRoute
+	err, ok := b.getForEntity(&route, uriSys, uriMgmtRoute, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &route, nil
+}
+
+// CreateManagementRoute adds a new management route to <network> via
+// <gateway>.
+func (b *BigIP) CreateManagementRoute(name, network, gateway string) error {
+	config := &ManagementRoute{
+		Name:    name,
+		Network: network,
+		Gateway: gateway,
+	}
+
+	return b.post(config, uriSys, uriMgmtRoute)
+}
+
+// AddManagementRoute adds a new management route by config.
+func (b *BigIP) AddManagementRoute(config *ManagementRoute) error {
+	return b.post(config, uriSys, uriMgmtRoute)
+}
+
+// DeleteManagementRoute removes a management route.
+func (b *BigIP) DeleteManagementRoute(name string) error {
+	return b.delete(uriSys, uriMgmtRoute, name)
+}
+
+// ModifyManagementRoute allows you to change any attribute of a management
+// route. This replaces the existing configuration, so use
+// PatchManagementRoute if you want to change only particular attributes.
+func (b *BigIP) ModifyManagementRoute(name string, config *ManagementRoute) error {
+	return b.put(config, uriSys, uriMgmtRoute, name)
+}
+
+// PatchManagementRoute changes only the attributes provided of a management
+// route.
+func (b *BigIP) PatchManagementRoute(name string, config *ManagementRoute) error {
+	return b.patch(config, uriSys, uriMgmtRoute, name)
+}
+
 // Folders contains a list of every folder on the BIG-IP system.
 type Folders struct {
 	Folders []Folder `json:"items"`
@@ -403,3 +932,1740 @@ func buildSysConfigOptions(fileName string, passphrase string) []map[string]inte
 	}
 	return options
 }
+
+// UcsArchives contains a list of the UCS archives on the BIG-IP system.
+type UcsArchives struct {
+	UcsArchives []UcsArchive
+}
+
+// UcsArchive describes a UCS archive stored in /var/local/ucs.
+type UcsArchive struct {
+	Name      string
+	Path      string
+	Size      int64
+	Created   time.Time
+	Version   string
+	Build     string
+	Hostname  string
+	Encrypted bool
+}
+
+// The API reports UCS archives only through their raw tmsh values.
+type ucsArchivesDTO struct {
+	Items []struct {
+		APIRawValues map[string]string `json:"apiRawValues"`
+	} `json:"items"`
+}
+
+// UcsCommand is used to save and load UCS archives.
+type UcsCommand struct {
+	Command string                   `json:"command"`
+	Name    string                   `json:"name"`
+	Options []map[string]interface{} `json:"options,omitempty"`
+}
+
+// UcsRestoreOptions controls how a UCS archive is loaded.
+type UcsRestoreOptions struct {
+	NoLicense       bool
+	NoPlatformCheck bool
+	ResetTrust      bool
+	Passphrase      string
+}
+
+// UcsArchives returns a list of UCS archives.
+func (b *BigIP) UcsArchives() (*UcsArchives, error) {
+	var dto ucsArchivesDTO
+	err, _ := b.getForEntity(&dto, uriSys, uriUcs)
+	if err != nil {
+		return nil, err
+	}
+
+	archives := &UcsArchives{}
+	for _, item := range dto.Items {
+		raw := item.APIRawValues
+		archive := UcsArchive{
+			Name:      path.Base(raw["filename"]),
+			Path:      raw["filename"],
+			Version:   raw["version"],
+			Build:     raw["build"],
+			Hostname:  raw["hostname"],
+			Encrypted: raw["encrypted"] == "yes",
+		}
+		// file_size is reported as i.e.: "223232 (in bytes)"
+		if fields := strings.Fields(raw["file_size"]); len(fields) > 0 {
+			archive.Size, _ = strconv.ParseInt(fields[0], 10, 64)
+		}
+		archive.Created, _ = time.Parse(time.RFC3339, raw["file_created_date"])
+		archives.UcsArchives = append(archives.UcsArchives, archive)
+	}
+
+	return archives, nil
+}
+
+// CreateUcs saves the configuration to a UCS archive named <name> and waits
+// for the save to finish. The archive is encrypted when passphrase is set.
+func (b *BigIP) CreateUcs(name, passphrase string, timeout time.Duration) error {
+	config := &UcsCommand{
+		Command: "save",
+		Name:    name,
+	}
+	if passphrase != "" {
+		config.Options = append(config.Options, map[string]interface{}{"passphrase": passphrase})
+	}
+	_, err := b.runTask(config, timeout, uriSys, uriUcs)
+	return err
+}
+
+// RestoreUcs loads the UCS archive <name> and waits for the load to finish.
+// Services restart while the archive is loaded, so allow a generous timeout.
+// opts may be nil.
+func (b *BigIP) RestoreUcs(name string, opts *UcsRestoreOptions, timeout time.Duration) error {
+	config := &UcsCommand{
+		Command: "load",
+		Name:    name,
+	}
+	if opts != nil {
+		if opts.NoLicense {
+			config.Options = append(config.Options, map[string]interface{}{"no-license": ""})
+		}
+		if opts.NoPlatformCheck {
+			config.Options = append(config.Options, map[string]interface{}{"no-platform-check": ""})
+		}
+		if opts.ResetTrust {
+			config.Options = append(config.Options, map[string]interface{}{"reset-trust": ""})
+		}
+		if opts.Passphrase != "" {
+			config.Options = append(config.Options, map[string]interface{}{"passphrase": opts.Passphrase})
+		}
+	}
+	_, err := b.runTask(config, timeout, uriSys, uriUcs)
+	return err
+}
+
+// DeleteUcs removes a UCS archive.
+func (b *BigIP) DeleteUcs(name string) error {
+	return b.delete(uriSys, uriUcs, name)
+}
+
+// UploadUcs uploads a UCS archive and moves it to /var/local/ucs so it can
+// be restored with RestoreUcs.
+func (b *BigIP) UploadUcs(r io.ReaderAt, size int64, filename string) (*Upload, error) {
+	upload, err := b.Upload(r, size, uriShared, uriFileTransfer, uriUploads, filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.UnixMv(downloadsDir+filename, ucsDir+filename); err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// CopyUcs copies the UCS archive <name> from this BIG-IP system to <dst>,
+// staging it in a temporary file, and verifies the download.
+func (b *BigIP) CopyUcs(dst *BigIP, name string) error {
+	f, err := ioutil.TempFile("", "ucs")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	dl, err := b.DownloadUcsFile(f, name, &DownloadOptions{Verify: true})
+	if err != nil {
+		return fmt.Errorf("downloading %s: %s", name, err)
+	}
+	if _, err := dst.UploadUcs(f, dl.TotalByteCount, name); err != nil {
+		return fmt.Errorf("uploading %s: %s", name, err)
+	}
+
+	return nil
+}
+
+// Reboot restarts the BIG-IP system. The API stops responding shortly after
+// the call returns; use RebootAndWait to wait for the system to come back.
+func (b *BigIP) Reboot() error {
+	return b.RebootToVolume("")
+}
+
+// RebootToVolume restarts the BIG-IP system into the software volume <volume>,
+// i.e.: "HD1.2". An empty volume reboots into the current boot location. The
+// version is detected again on next use.
+func (b *BigIP) RebootToVolume(volume string) error {
+	config := struct {
+		Command string `json:"command"`
+		Volume  string `json:"volume,omitempty"`
+	}{Command: "reboot",
+		Volume: volume}
+
+	if err := b.post(config, uriSys); err != nil {
+		return err
+	}
+	// The system may come back with other software, i.e. after an upgrade.
+	b.resetTMOSVersion()
+	return nil
+}
+
+// Provisions contains the provisioning of every module.
+type Provisions struct {
+	Provisions []Provision `json:"items"`
+}
+
+// Provision contains the provisioning level of a module, i.e. "ltm" or "asm".
+type Provision struct {
+	Name        string `json:"name,omitempty"`
+	FullPath    string `json:"fullPath,omitempty"`
+	Generation  int    `json:"generation,omitempty"`
+	CpuRatio    int    `json:"cpuRatio,omitempty"`
+	DiskRatio   int    `json:"diskRatio,omitempty"`
+	Level       string `json:"level,omitempty"`
+	MemoryRatio int    `json:"memoryRatio,omitempty"`
+}
+
+// Provisions returns the provisioning of every module.
+func (b *BigIP) Provisions() (*Provisions, error) {
+	var provisions Provisions
+	err, _ := b.getForEntity(&provisions, uriSys, uriProvision)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provisions, nil
+}
+
+// GetProvision returns the provisioning of the module <name>. Returns nil if
+// the module does not exist on this platform.
+func (b *BigIP) GetProvision(name string) (*Provision, error) {
+	var provision Provision
+	err, ok := b.getForEntity(&provision, uriSys, uriProvision, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &provision, nil
+}
+
+// ProvisionModule changes the provisioning level of a single module. The
+// change restarts services; use SetProvisioning to validate the change and
+// wait for it to complete.
+func (b *BigIP) ProvisionModule(name, level string) error {
+	config := &Provision{
+		Level: level,
+	}
+	return b.patch(config, uriSys, uriProvision, name)
+}
+
+// hostMemory returns the memory of the host in bytes.
+func (b *BigIP) hostMemory() (int64, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriSys, uriHostInfo)
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range stats.records() {
+		if total := r["memoryTotal"].Value; total > 0 {
+			return total, nil
+		}
+	}
+
+	return 0, errors.New("host info does not report the memory")
+}
+
+// ValidateProvisioning checks whether the modules can be provisioned at the
+// given levels, i.e.: map[string]string{"ltm": ProvisionNominal, "asm":
+// ProvisionNominal}. Modules that are not mentioned keep their current
+// level. It verifies the levels are valid, the modules are licensed, a
+// dedicated module is the only one provisioned and the system has enough
+// memory.
+func (b *BigIP) ValidateProvisioning(levels map[string]string) error {
+	provisions, err := b.Provisions()
+	if err != nil {
+		return err
+	}
+	license, err := b.GetLicenseState()
+	if err != nil {
+		return err
+	}
+
+	merged := make(map[string]string)
+	for _, p := range provisions.Provisions {
+		merged[p.Name] = p.Level
+	}
+	var problems []string
+	for name, level := range levels {
+		if _, ok := merged[name]; !ok {
+			problems = append(problems, fmt.Sprintf("module %s does not exist", name))
+			continue
+		}
+		if !provisionLevels[level] {
+			problems = append(problems, fmt.Sprintf("invalid level %q for module %s", level, name))
+			continue
+		}
+		merged[name] = level
+		if level != ProvisionNone && !moduleLicensed(license, name) {
+			problems = append(problems, fmt.Sprintf("module %s is not licensed", name))
+		}
+	}
+
+	var provisioned []string
+	dedicated := false
+	for name, level := range merged {
+		if level == ProvisionNone || level == "" {
+			continue
+		}
+		provisioned = append(provisioned, name)
+		dedicated = dedicated || level == ProvisionDedicated
+	}
+	sort.Strings(provisioned)
+	if dedicated && len(provisioned) > 1 {
+		problems = append(problems, fmt.Sprintf("a dedicated module can't be provisioned with others: %s", strings.Join(provisioned, ", ")))
+	}
+
+	memory, err := b.hostMemory()
+	if err != nil {
+		return err
+	}
+	// Allow for memory reserved by the hypervisor or kernel.
+	required := int64(len(provisioned)) * provisionMemoryPerModule
+	if memory < required*9/10 {
+		problems = append(problems, fmt.Sprintf("%d modules need %d MB of memory, the system has %d MB", len(provisioned), required>>20, memory>>20))
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("Invalid provisioning: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// moduleLicensed reports whether the module <name> is licensed. Modules that
+// don't need a license of their own, i.e. avr, are always licensed.
+func moduleLicensed(license *LicenseState, name string) bool {
+	if _, ok := licenseModuleNames[name]; !ok {
+		return true
+	}
+	var modules []LicenseModule
+	for _, m := range license.ActiveModules {
+		modules = append(modules, parseLicenseModule(m))
+	}
+	flags := make(map[string]string)
+	for _, f := range license.FeatureFlags {
+		flags[f.FeatureName] = f.FeatureValue
+	}
+	return licensedModule(modules, flags, name)
+}
+
+// SetProvisioning validates and changes the provisioning levels of the given
+// modules in one step, then waits until the system restarted its services
+// and is ready again. progress, if set, is called while waiting.
+func (b *BigIP) SetProvisioning(levels map[string]string, timeout time.Duration, progress func(*ReadyStatus)) error {
+	deadline := time.Now().Add(timeout)
+	if err := b.ValidateProvisioning(levels); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	command := "modify sys provision"
+	for _, name := range names {
+		command += fmt.Sprintf(" %s { level %s }", name, levels[name])
+	}
+	result, err := b.RunTmsh(command)
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("Failed to change provisioning: %s", strings.TrimSpace(result.Output))
+	}
+
+	// mcpd does not restart right away, don't mistake the state before the
+	// restart for the end of it.
+	grace := time.Now().Add(provisionRestartGrace)
+	for time.Now().Before(grace) && time.Now().Before(deadline) {
+		status := b.GetReadyStatus()
+		if progress != nil {
+			progress(status)
+		}
+		if !status.Ready() {
+			break
+		}
+		time.Sleep(readyPollInterval)
+	}
+
+	return b.WaitUntilReady(time.Until(deadline), progress)
+}
+
+// ReadyStatus reports which services of the BIG-IP system are ready.
+type ReadyStatus struct {
+	RestAPI        bool
+	McpdRunning    bool
+	ConfigReady    bool
+	LicenseReady   bool
+	ProvisionReady bool
+	FailoverState  FailoverState
+	// Err is the error of the check that failed, if any.
+	Err error
+}
+
+// Ready reports whether the system is fully usable: the API responds, mcpd
+// runs, config, license and provisioning are loaded and the device is not
+// changing its failover state.
+func (r *ReadyStatus) Ready() bool {
+	return r.RestAPI && r.McpdRunning && r.ConfigReady && r.LicenseReady && r.ProvisionReady && r.FailoverState.Settled()
+}
+
+// GetReadyStatus checks the services of the BIG-IP system in the order they
+// start, stopping at the first one that is not ready.
+func (b *BigIP) GetReadyStatus() *ReadyStatus {
+	status := &ReadyStatus{}
+
+	var echo map[string]interface{}
+	if status.Err, _ = b.getForEntity(&echo, uriMgmt, uriShared, uriEcho); status.Err != nil {
+		return status
+	}
+	status.RestAPI = true
+
+	var mcp Stats
+	if status.Err, _ = b.getForEntity(&mcp, uriSys, uriMcpState); status.Err != nil {
+		return status
+	}
+	for _, r := range mcp.records() {
+		status.McpdRunning = r["phase"].Description == "running"
+	}
+	if !status.McpdRunning {
+		return status
+	}
+
+	var ready Stats
+	if status.Err, _ = b.getForEntity(&ready, uriSys, uriReady); status.Err != nil {
+		return status
+	}
+	for _, r := range ready.records() {
+		status.ConfigReady = r["configReady"].Description == "yes"
+		status.LicenseReady = r["licenseReady"].Description == "yes"
+		status.ProvisionReady = r["provisionReady"].Description == "yes"
+	}
+
+	device, err := b.GetCurrentDevice()
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.FailoverState = device.FailoverState
+
+	return status
+}
+
+// WaitUntilReady polls the BIG-IP system until GetReadyStatus reports it is
+// ready or the timeout expires. progress, if set, is called with each status.
+func (b *BigIP) WaitUntilReady(timeout time.Duration, progress func(*ReadyStatus)) error {
+	deadline := time.Now().Add(timeout)
+	var status *ReadyStatus
+	for time.Now().Before(deadline) {
+		status = b.GetReadyStatus()
+		if progress != nil {
+			progress(status)
+		}
+		if status.Ready() {
+			return nil
+		}
+		time.Sleep(readyPollInterval)
+	}
+
+	if status != nil && status.Err != nil {
+		return fmt.Errorf("Timed out after %s: %s", timeout, status.Err)
+	}
+	return fmt.Errorf("Timed out after %s", timeout)
+}
+
+// RebootAndWait reboots the BIG-IP system, waits for it to go down and then
+// waits until it is ready again.
+func (b *BigIP) RebootAndWait(timeout time.Duration, progress func(*ReadyStatus)) error {
+	deadline := time.Now().Add(timeout)
+	if err := b.Reboot(); err != nil {
+		return err
+	}
+
+	for {
+		status := b.GetReadyStatus()
+		if progress != nil {
+			progress(status)
+		}
+		if !status.RestAPI || !status.McpdRunning {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("Timed out after %s waiting for the system to go down", timeout)
+		}
+		time.Sleep(readyPollInterval)
+	}
+
+	return b.WaitUntilReady(time.Until(deadline), progress)
+}
+
+// DbVariables contains a list of every db variable.
+type DbVariables struct {
+	DbVariables []DbVariable `json:"items"`
+}
+
+// DbVariable contains a db variable, i.e. "ui.advisory.enabled".
+type DbVariable struct {
+	Name         string `json:"name,omitempty"`
+	FullPath     string `json:"fullPath,omitempty"`
+	Generation   int    `json:"generation,omitempty"`
+	DefaultValue string `json:"defaultValue,omitempty"`
+	ScfConfig    string `json:"scfConfig,omitempty"`
+	Value        string `json:"value,omitempty"`
+	ValueRange   string `json:"valueRange,omitempty"`
+}
+
+// IsDefault reports whether the variable has its default value.
+func (d *DbVariable) IsDefault() bool {
+	return dbValuesEqual(d.Value, d.DefaultValue)
+}
+
+// dbValuesEqual reports whether two db variable values are the same. The
+// system doesn't distinguish case, i.e. "Enable" is "enable".
+func dbValuesEqual(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// DbVariables returns a list of db variables.
+func (b *BigIP) DbVariables() (*DbVariables, error) {
+	var variables DbVariables
+	err, _ := b.getForEntity(&variables, uriSys, uriDb)
+	if err != nil {
+		return nil, err
+	}
+
+	return &variables, nil
+}
+
+// GetDbVariable retrieves a db variable by name. Returns nil if the variable
+// does not exist.
+func (b *BigIP) GetDbVariable(name string) (*DbVariable, error) {
+	var variable DbVariable
+	err, ok := b.getForEntity(&variable, uriSys, uriDb, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &variable, nil
+}
+
+// SetDbVariable changes the value of a db variable. The value may be empty.
+func (b *BigIP) SetDbVariable(name, value string) error {
+	config := struct {
+		Value string `json:"value"`
+	}{value}
+	return b.patch(config, uriSys, uriDb, name)
+}
+
+// ResetDbVariable sets a db variable back to its default value.
+func (b *BigIP) ResetDbVariable(name string) error {
+	variable, err := b.GetDbVariable(name)
+	if err != nil {
+		return err
+	}
+	if variable == nil {
+		return fmt.Errorf("Db variable %s does not exist", name)
+	}
+	if variable.IsDefault() {
+		return nil
+	}
+
+	return b.SetDbVariable(name, variable.DefaultValue)
+}
+
+// SetDbVariables changes the values of several db variables. Variables that
+// already have the requested value, ignoring case, are left alone. It returns the names of
+// the changed variables; on error those changed before the failure.
+func (b *BigIP) SetDbVariables(values map[string]string) ([]string, error) {
+	variables, err := b.DbVariables()
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]string)
+	for _, v := range variables.DbVariables {
+		current[v.Name] = v.Value
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var changed []string
+	for _, name := range names {
+		value, ok := current[name]
+		if !ok {
+			return changed, fmt.Errorf("Db variable %s does not exist", name)
+		}
+		if dbValuesEqual(value, values[name]) {
+			continue
+		}
+		if err := b.SetDbVariable(name, values[name]); err != nil {
+			return changed, fmt.Errorf("Failed to set db variable %s: %s", name, err)
+		}
+		changed = append(changed, name)
+	}
+
+	return changed, nil
+}
+
+// NonDefaultDbVariables returns the db variables that differ from their
+// default value.
+func (b *BigIP) NonDefaultDbVariables() ([]DbVariable, error) {
+	variables, err := b.DbVariables()
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []DbVariable
+	for _, v := range variables.DbVariables {
+		if !v.IsDefault() {
+			changed = append(changed, v)
+		}
+	}
+
+	return changed, nil
+}
+
+// SystemVersion contains the version of the running software.
+type SystemVersion struct {
+	Product string `json:"product,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version,omitempty"`
+	Build   string `json:"build,omitempty"`
+	Edition string `json:"edition,omitempty"`
+	Date    string `json:"date,omitempty"`
+}
+
+// LogicalDisks contains a list of every logical disk.
+type LogicalDisks struct {
+	LogicalDisks []LogicalDisk `json:"items"`
+}
+
+// LogicalDisk contains the size and usage of a logical disk in MB.
+type LogicalDisk struct {
+	Name       string `json:"name,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+	Size       int64  `json:"size,omitempty"`
+	VgFree     int64  `json:"vgFree,omitempty"`
+	VgInUse    int64  `json:"vgInUse,omitempty"`
+	VgReserved int64  `json:"vgReserved,omitempty"`
+}
+
+// ClusterMembers contains the blades of a chassis.
+type ClusterMembers struct {
+	ClusterMembers []ClusterMember `json:"items"`
+}
+
+// ClusterMember is a blade of a chassis, named after its slot.
+type ClusterMember struct {
+	Name    string `json:"name,omitempty"`
+	Enabled bool   `json:"enabled,omitempty"`
+	Priming bool   `json:"priming,omitempty"`
+}
+
+// Inventory describes the hardware and software of a system.
+type Inventory struct {
+	Hostname      string          `json:"hostname,omitempty"`
+	Product       string          `json:"product,omitempty"`
+	MarketingName string          `json:"marketingName,omitempty"`
+	Platform      string          `json:"platform,omitempty"`
+	SerialNumber  string          `json:"serialNumber,omitempty"`
+	ChassisId     string          `json:"chassisId,omitempty"`
+	BaseMac       string          `json:"baseMac,omitempty"`
+	Version       string          `json:"version,omitempty"`
+	Build         string          `json:"build,omitempty"`
+	Edition       string          `json:"edition,omitempty"`
+	CpuCount      int64           `json:"cpuCount,omitempty"`
+	MemoryTotal   int64           `json:"memoryTotal,omitempty"` // bytes
+	Disks         []LogicalDisk   `json:"disks,omitempty"`
+	Blades        []ClusterMember `json:"blades,omitempty"`
+	Interfaces    []Interface     `json:"interfaces,omitempty"`
+}
+
+// GetSystemVersion returns the version of the running software.
+func (b *BigIP) GetSystemVersion() (*SystemVersion, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriSys, uriVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range stats.records() {
+		return &SystemVersion{
+			Product: r["Product"].Description,
+			Title:   r["Title"].Description,
+			Version: r["Version"].Description,
+			Build:   r["Build"].Description,
+			Edition: r["Edition"].Description,
+			Date:    r["Date"].Description,
+		}, nil
+	}
+	return nil, errors.New("sys version is empty")
+}
+
+// LogicalDisks returns a list of logical disks.
+func (b *BigIP) LogicalDisks() (*LogicalDisks, error) {
+	var disks LogicalDisks
+	err, _ := b.getForEntity(&disks, uriSys, uriDisk, uriLogical)
+	if err != nil {
+		return nil, err
+	}
+
+	return &disks, nil
+}
+
+// ClusterMembers returns the blades of a chassis. Platforms that are not a
+// chassis have no blades.
+func (b *BigIP) ClusterMembers() (*ClusterMembers, error) {
+	var members ClusterMembers
+	err, _ := b.getForEntity(&members, uriSys, uriCluster, "default", uriMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &members, nil
+}
+
+// hardwareSection returns the first record of a section of the hardware
+// stats, i.e. "platform" or "system-info".
+func hardwareSection(stats *Stats, name string) map[string]StatsEntry {
+	for k, e := range stats.Entries {
+		if !strings.HasSuffix(k, "/"+name) || e.NestedStats == nil {
+			continue
+		}
+		for _, r := range e.NestedStats.records() {
+			return r
+		}
+	}
+	return map[string]StatsEntry{}
+}
+
+// GetInventory collects the platform, serial numbers, software version,
+// disks, memory, blades and interfaces of the system.
+func (b *BigIP) GetInventory() (*Inventory, error) {
+	var hardware Stats
+	err, _ := b.getForEntity(&hardware, uriSys, uriHardware)
+	if err != nil {
+		return nil, err
+	}
+	platform := hardwareSection(&hardware, "platform")
+	system := hardwareSection(&hardware, "system-info")
+	inventory := &Inventory{
+		Product:       system["product"].Description,
+		MarketingName: platform["marketingName"].Description,
+		Platform:      system["platform"].Description,
+		SerialNumber:  system["bigipChassisSerialNum"].Description,
+		BaseMac:       platform["baseMac"].Description,
+	}
+
+	settings, err := b.GlobalSettings()
+	if err != nil {
+		return nil, err
+	}
+	inventory.Hostname = settings.Hostname
+
+	device, err := b.GetCurrentDevice()
+	if err != nil {
+		return nil, err
+	}
+	inventory.ChassisId = device.ChassisId
+
+	version, err := b.GetSystemVersion()
+	if err != nil {
+		return nil, err
+	}
+	inventory.Version = version.Version
+	inventory.Build = version.Build
+	inventory.Edition = version.Edition
+
+	var host Stats
+	if err, _ := b.getForEntity(&host, uriSys, uriHostInfo); err != nil {
+		return nil, err
+	}
+	for _, r := range host.records() {
+		inventory.CpuCount = r["cpuCount"].Value
+		inventory.MemoryTotal = r["memoryTotal"].Value
+	}
+
+	disks, err := b.LogicalDisks()
+	if err != nil {
+		return nil, err
+	}
+	inventory.Disks = disks.LogicalDisks
+
+	blades, err := b.ClusterMembers()
+	if err != nil {
+		return nil, err
+	}
+	inventory.Blades = blades.ClusterMembers
+
+	interfaces, err := b.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	inventory.Interfaces = interfaces.Interfaces
+
+	return inventory, nil
+}
+
+// statsSection returns the records of a nested section of stats, i.e. the
+// "memory-host" section of the memory stats.
+func statsSection(stats *Stats, name string) []map[string]StatsEntry {
+	var records []map[string]StatsEntry
+	for k, e := range stats.Entries {
+		if strings.HasSuffix(k, "/"+name) && e.NestedStats != nil {
+			records = append(records, e.NestedStats.records()...)
+		}
+	}
+	return records
+}
+
+// HostInfo contains the CPUs and memory of the host.
+type HostInfo struct {
+	CpuCount       int64
+	ActiveCpuCount int64
+	MemoryTotal    int64 // bytes
+	MemoryUsed     int64 // bytes
+}
+
+// TMMInfo contains the CPU and memory usage of a TMM instance.
+type TMMInfo struct {
+	TmmId           string
+	CpuId           int64
+	OneSecAvgUsage  int64 // percent
+	FiveSecAvgUsage int64 // percent
+	OneMinAvgUsage  int64 // percent
+	FiveMinAvgUsage int64 // percent
+	MemoryTotal     int64 // bytes
+	MemoryUsed      int64 // bytes
+}
+
+// CPUStats contains the usage of a CPU of the host. The counters are the
+// time spent in each state since boot in jiffies, use RatesSince on two
+// samples to get the utilization.
+type CPUStats struct {
+	CpuId          int64
+	User           int64
+	Niced          int64
+	System         int64
+	Idle           int64
+	Irq            int64
+	Softirq        int64
+	Iowait         int64
+	FiveSecAvgIdle int64 // percent
+	OneMinAvgIdle  int64 // percent
+	FiveMinAvgIdle int64 // percent
+}
+
+// busy returns the jiffies the CPU was not idle.
+func (c *CPUStats) busy() int64 {
+	return c.User + c.Niced + c.System + c.Irq + c.Softirq + c.Iowait
+}
+
+// MemoryStats contains the memory usage of the host, split into the memory
+// of TMM and everything else.
+type MemoryStats struct {
+	MemoryTotal      int64 // bytes
+	MemoryUsed       int64 // bytes
+	TmmMemoryTotal   int64 // bytes
+	TmmMemoryUsed    int64 // bytes
+	OtherMemoryTotal int64 // bytes
+	OtherMemoryUsed  int64 // bytes
+	SwapTotal        int64 // bytes
+	SwapUsed         int64 // bytes
+}
+
+// TrafficStats contains the traffic counters of all TMM instances since
+// they started.
+type TrafficStats struct {
+	ClientBitsIn   int64
+	ClientBitsOut  int64
+	ClientCurConns int64
+	ClientTotConns int64
+	ServerBitsIn   int64
+	ServerBitsOut  int64
+	ServerCurConns int64
+	ServerTotConns int64
+}
+
+// PerformanceMetric is a line of a performance graph, i.e. "Client Bits In"
+// of the "Throughput(bits)" graph. The unit is part of the graph name.
+type PerformanceMetric struct {
+	Name    string
+	Graph   string
+	Current float64
+	Average float64
+	Max     float64
+}
+
+// HostInfo returns the CPUs and memory of the host.
+func (b *BigIP) HostInfo() (*HostInfo, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriSys, uriHostInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &HostInfo{}
+	for _, r := range stats.records() {
+		info.CpuCount += r["cpuCount"].Value
+		info.ActiveCpuCount += r["activeCpuCount"].Value
+		info.MemoryTotal += r["memoryTotal"].Value
+		info.MemoryUsed += r["memoryUsed"].Value
+	}
+	return info, nil
+}
+
+// TMMInfo returns the usage of each TMM instance.
+func (b *BigIP) TMMInfo() ([]TMMInfo, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriSys, uriTmmInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var tmms []TMMInfo
+	for _, r := range stats.records() {
+		tmms = append(tmms, TMMInfo{
+			TmmId:           r["tmmId"].Description,
+			CpuId:           r["cpuId"].Value,
+			OneSecAvgUsage:  r["oneSecAvgUsageRatio"].Value,
+			FiveSecAvgUsage: r["fiveSecAvgUsageRatio"].Value,
+			OneMinAvgUsage:  r["oneMinAvgUsageRatio"].Value,
+			FiveMinAvgUsage: r["fiveMinAvgUsageRatio"].Value,
+			MemoryTotal:     r["memoryTotal"].Value,
+			MemoryUsed:      r["memoryUsed"].Value,
+		})
+	}
+	return tmms, nil
+}
+
+// CPUStats returns the usage of each CPU of the host.
+func (b *BigIP) CPUStats() ([]CPUStats, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriSys, uriCpu)
+	if err != nil {
+		return nil, err
+	}
+
+	var cpus []CPUStats
+	for _, host := range stats.records() {
+		info := Stats{Entries: host}
+		for _, r := range statsSection(&info, "cpuInfo") {
+			cpus = append(cpus, CPUStats{
+				CpuId:          r["cpuId"].Value,
+				User:           r["user"].Value,
+				Niced:          r["niced"].Value,
+				System:         r["system"].Value,
+				Idle:           r["idle"].Value,
+				Irq:            r["irq"].Value,
+				Softirq:        r["softirq"].Value,
+				Iowait:         r["iowait"].Value,
+				FiveSecAvgIdle: r["fiveSecAvgIdle"].Value,
+				OneMinAvgIdle:  r["oneMinAvgIdle"].Value,
+				FiveMinAvgIdle: r["fiveMinAvgIdle"].Value,
+			})
+		}
+	}
+	return cpus, nil
+}
+
+// MemoryStats returns the memory usage of the host.
+func (b *BigIP) MemoryStats() (*MemoryStats, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriSys, uriMemory)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := &MemoryStats{}
+	for _, r := range statsSection(&stats, "memory-host") {
+		memory.MemoryTotal += r["memoryTotal"].Value
+		memory.MemoryUsed += r["memoryUsed"].Value
+		memory.TmmMemoryTotal += r["tmmMemoryTotal"].Value
+		memory.TmmMemoryUsed += r["tmmMemoryUsed"].Value
+		memory.OtherMemoryTotal += r["otherMemoryTotal"].Value
+		memory.OtherMemoryUsed += r["otherMemoryUsed"].Value
+		memory.SwapTotal += r["swapTotal"].Value
+		memory.SwapUsed += r["swapUsed"].Value
+	}
+	return memory, nil
+}
+
+// TrafficStats returns the traffic counters summed over all TMM instances.
+func (b *BigIP) TrafficStats() (*TrafficStats, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriSys, uriTraffic)
+	if err != nil {
+		return nil, err
+	}
+
+	traffic := &TrafficStats{}
+	for _, r := range stats.records() {
+		traffic.ClientBitsIn += r["clientSideTraffic.bitsIn"].Value
+		traffic.ClientBitsOut += r["clientSideTraffic.bitsOut"].Value
+		traffic.ClientCurConns += r["clientSideTraffic.curConns"].Value
+		traffic.ClientTotConns += r["clientSideTraffic.totConns"].Value
+		traffic.ServerBitsIn += r["serverSideTraffic.bitsIn"].Value
+		traffic.ServerBitsOut += r["serverSideTraffic.bitsOut"].Value
+		traffic.ServerCurConns += r["serverSideTraffic.curConns"].Value
+		traffic.ServerTotConns += r["serverSideTraffic.totConns"].Value
+	}
+	return traffic, nil
+}
+
+// parsePerformanceValue parses a value of the performance graphs, which may
+// be abbreviated, i.e. "12.5K".
+func parsePerformanceValue(s string) float64 {
+	multiplier := 1.0
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'K':
+			multiplier = 1e3
+		case 'M':
+			multiplier = 1e6
+		case 'G':
+			multiplier = 1e9
+		case 'T':
+			multiplier = 1e12
+		}
+		if multiplier != 1 {
+			s = s[:n-1]
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v * multiplier
+}
+
+// PerformanceStats returns the metrics of all performance graphs, i.e.
+// throughput, connections and CPU usage, sorted by graph and name.
+func (b *BigIP) PerformanceStats() ([]PerformanceMetric, error) {
+	var stats Stats
+	err, _ := b.getForEntity(&stats, uriSys, uriPerf, uriAllStats)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []PerformanceMetric
+	for k, e := range stats.Entries {
+		if e.NestedStats == nil {
+			continue
+		}
+		name, err := url.PathUnescape(path.Base(k))
+		if err != nil {
+			name = path.Base(k)
+		}
+		m := PerformanceMetric{Name: name}
+		for field, v := range e.NestedStats.Entries {
+			field, _ = url.PathUnescape(field)
+			switch {
+			case field == "Graph Name":
+				m.Graph = v.Description
+			case field == "Current":
+				m.Current = parsePerformanceValue(v.Description)
+			case field == "Average":
+				m.Average = parsePerformanceValue(v.Description)
+			case strings.HasPrefix(field, "Max"):
+				m.Max = parsePerformanceValue(v.Description)
+			}
+		}
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Graph != metrics[j].Graph {
+			return metrics[i].Graph < metrics[j].Graph
+		}
+		return metrics[i].Name < metrics[j].Name
+	})
+	return metrics, nil
+}
+
+// PerformanceSample is a reading of the CPU and traffic counters.
+type PerformanceSample struct {
+	Time    time.Time
+	CPUs    []CPUStats
+	Traffic TrafficStats
+}
+
+// PerformanceRates are the rates between two samples.
+type PerformanceRates struct {
+	Interval            time.Duration
+	CPUUsage            float64 // percent, averaged over all CPUs
+	ClientBitsInPerSec  float64
+	ClientBitsOutPerSec float64
+	ServerBitsInPerSec  float64
+	ServerBitsOutPerSec float64
+	ClientConnsPerSec   float64
+	ServerConnsPerSec   float64
+}
+
+// SamplePerformance reads the CPU and traffic counters.
+func (b *BigIP) SamplePerformance() (*PerformanceSample, error) {
+	cpus, err := b.CPUStats()
+	if err != nil {
+		return nil, err
+	}
+	traffic, err := b.TrafficStats()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PerformanceSample{Time: time.Now(), CPUs: cpus, Traffic: *traffic}, nil
+}
+
+// RatesSince returns the rates between sample <prev> and s. Counters that
+// went backwards, i.e. after a restart, count as zero.
+func (s *PerformanceSample) RatesSince(prev *PerformanceSample) *PerformanceRates {
+	rates := &PerformanceRates{Interval: s.Time.Sub(prev.Time)}
+	seconds := rates.Interval.Seconds()
+	if seconds <= 0 {
+		return rates
+	}
+	perSec := func(now, before int64) float64 {
+		if now < before {
+			return 0
+		}
+		return float64(now-before) / seconds
+	}
+
+	previous := make(map[int64]CPUStats, len(prev.CPUs))
+	for _, c := range prev.CPUs {
+		previous[c.CpuId] = c
+	}
+	var busy, total int64
+	for _, c := range s.CPUs {
+		p, ok := previous[c.CpuId]
+		if !ok || c.busy() < p.busy() || c.Idle < p.Idle {
+			continue
+		}
+		busy += c.busy() - p.busy()
+		total += c.busy() - p.busy() + c.Idle - p.Idle
+	}
+	if total > 0 {
+		rates.CPUUsage = float64(busy) * 100 / float64(total)
+	}
+
+	now, before := s.Traffic, prev.Traffic
+	rates.ClientBitsInPerSec = perSec(now.ClientBitsIn, before.ClientBitsIn)
+	rates.ClientBitsOutPerSec = perSec(now.ClientBitsOut, before.ClientBitsOut)
+	rates.ServerBitsInPerSec = perSec(now.ServerBitsIn, before.ServerBitsIn)
+	rates.ServerBitsOutPerSec = perSec(now.ServerBitsOut, before.ServerBitsOut)
+	rates.ClientConnsPerSec = perSec(now.ClientTotConns, before.ClientTotConns)
+	rates.ServerConnsPerSec = perSec(now.ServerTotConns, before.ServerTotConns)
+	return rates
+}
+
+// MeasurePerformance takes two samples <interval> apart and returns the rates
+// between them.
+func (b *BigIP) MeasurePerformance(interval time.Duration) (*PerformanceRates, error) {
+	first, err := b.SamplePerformance()
+	if err != nil {
+		return nil, err
+	}
+	time.Sleep(interval)
+	second, err := b.SamplePerformance()
+	if err != nil {
+		return nil, err
+	}
+
+	return second.RatesSince(first), nil
+}
+
+// Log files that can be read with Logs.
+const (
+	LogLtm       = "/var/log/ltm"
+	LogAudit     = "/var/log/audit"
+	LogRestjavad = "/var/log/restjavad.0.log"
+)
+
+// Log severities, from most to least severe.
+const (
+	LogSeverityEmergency = "emerg"
+	LogSeverityAlert     = "alert"
+	LogSeverityCritical  = "crit"
+	LogSeverityError     = "err"
+	LogSeverityWarning   = "warning"
+	LogSeverityNotice    = "notice"
+	LogSeverityInfo      = "info"
+	LogSeverityDebug     = "debug"
+)
+
+var logSeverities = map[string]int{
+	LogSeverityEmergency: 0,
+	LogSeverityAlert:     1,
+	LogSeverityCritical:  2,
+	LogSeverityError:     3,
+	LogSeverityWarning:   4,
+	LogSeverityNotice:    5,
+	LogSeverityInfo:      6,
+	LogSeverityDebug:     7,
+}
+
+// restjavad logs the first letter of the java.util.logging level.
+var restjavadSeverities = map[string]string{
+	"S": LogSeverityError,
+	"W": LogSeverityWarning,
+	"I": LogSeverityInfo,
+	"C": LogSeverityInfo,
+	"F": LogSeverityDebug,
+}
+
+// defaultLogLines is how many lines Logs reads if the query doesn't say.
+const defaultLogLines = 1000
+
+var (
+	// Oct 19 10:12:01 bigip1 warning mcpd[6734]: 01070417:4: message
+	syslogLine = regexp.MustCompile(`^(\w{3} +\d+ \d\d:\d\d:\d\d|\d{4}-\d\d-\d\dT[^ ]+) (\S+) (\w+) ([^:\[ ]+)(?:\[(\d+)\])?: (.*)$`)
+	// [I][1234][19 Oct 2026 10:12:01 UTC][8100/shared/authn/login AuthnWorker] message
+	restjavadLine = regexp.MustCompile(`^\[(\w)\]\[(\d+)\]\[([^\]]+)\]\[([^\]]*)\] ?(.*)$`)
+)
+
+// LogQuery selects the entries returned by Logs. All filters are optional.
+type LogQuery struct {
+	File     string         // i.e. LogLtm, LogLtm if empty
+	Lines    int            // lines read from the end of the file, 1000 if zero
+	Since    time.Time      // entries at or after
+	Until    time.Time      // entries before
+	Severity string         // entries at least this severe, i.e. LogSeverityWarning
+	Pattern  *regexp.Regexp // entries whose message matches
+	Location *time.Location // time zone of the system, UTC if nil
+}
+
+// LogEntry is a parsed line of a log file. Lines that can't be parsed, i.e.
+// stack traces, are appended to the message of the entry before them.
+type LogEntry struct {
+	Time     time.Time
+	Host     string
+	Severity string
+	Process  string
+	Pid      int
+	Message  string
+}
+
+// LogTruncatedError is returned by Logs when the lines read don't reach back
+// to the Since of the query, so entries in the range may be missing. Read
+// more Lines to get them.
+type LogTruncatedError struct {
+	File   string
+	Lines  int
+	Oldest time.Time // time of the oldest entry read
+}
+
+func (e *LogTruncatedError) Error() string {
+	return fmt.Sprintf("the last %d lines of %s only reach back to %s",
+		e.Lines, e.File, e.Oldest.Format(time.RFC3339))
+}
+
+// parseLogEntry parses a line of a syslog or restjavad log. Syslog
+// timestamps have no year, the year is chosen so the time is not after <now>.
+func parseLogEntry(line string, loc *time.Location, now time.Time) (*LogEntry, bool) {
+	if m := syslogLine.FindStringSubmatch(line); m != nil {
+		var t time.Time
+		var err error
+		if strings.Contains(m[1], "T") {
+			t, err = time.Parse(time.RFC3339Nano, m[1])
+		} else {
+			t, err = time.ParseInLocation("2006 Jan _2 15:04:05", fmt.Sprintf("%d %s", now.Year(), m[1]), loc)
+			if err == nil && t.After(now.Add(24*time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+		}
+		if err != nil {
+			return nil, false
+		}
+		pid, _ := strconv.Atoi(m[5])
+		return &LogEntry{Time: t, Host: m[2], Severity: m[3], Process: m[4], Pid: pid, Message: m[6]}, true
+	}
+	if m := restjavadLine.FindStringSubmatch(line); m != nil {
+		t, err := time.Parse("02 Jan 2006 15:04:05 MST", m[3])
+		if err != nil {
+			return nil, false
+		}
+		severity, ok := restjavadSeverities[m[1]]
+		if !ok {
+			severity = LogSeverityInfo
+		}
+		message := m[5]
+		if m[4] != "" {
+			message = "[" + m[4] + "] " + message
+		}
+		return &LogEntry{Time: t, Severity: severity, Process: "restjavad", Message: message}, true
+	}
+	return nil, false
+}
+
+// parseLog parses the lines of a log file.
+func parseLog(output string, loc *time.Location, now time.Time) []LogEntry {
+	var entries []LogEntry
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if e, ok := parseLogEntry(line, loc, now); ok {
+			entries = append(entries, *e)
+		} else if len(entries) > 0 {
+			entries[len(entries)-1].Message += "\n" + line
+		}
+	}
+	return entries
+}
+
+// matches returns true if the entry passes the filters of the query.
+func (q *LogQuery) matches(e *LogEntry) bool {
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	if q.Severity != "" {
+		level, ok := logSeverities[e.Severity]
+		if !ok || level > logSeverities[q.Severity] {
+			return false
+		}
+	}
+	if q.Pattern != nil && !q.Pattern.MatchString(e.Message) {
+		return false
+	}
+	return true
+}
+
+// Logs reads the end of a log file on the system and returns the entries
+// that match the query, oldest first. A nil query returns the last 1000 lines
+// of LogLtm. If the lines read don't reach back to q.Since, the matching
+// entries are returned with a *LogTruncatedError.
+func (b *BigIP) Logs(query *LogQuery) ([]LogEntry, error) {
+	var q LogQuery
+	if query != nil {
+		q = *query
+	}
+	if q.File == "" {
+		q.File = LogLtm
+	}
+	if q.Lines <= 0 {
+		q.Lines = defaultLogLines
+	}
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	if _, ok := logSeverities[q.Severity]; q.Severity != "" && !ok {
+		return nil, fmt.Errorf("unknown log severity %q", q.Severity)
+	}
+
+	result, err := b.RunBashArgs("tail", "-n", strconv.Itoa(q.Lines), q.File)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("reading %s failed: %s", q.File, strings.TrimSpace(result.Output))
+	}
+
+	parsed := parseLog(result.Output, q.Location, time.Now().In(q.Location))
+	var entries []LogEntry
+	for _, e := range parsed {
+		if q.matches(&e) {
+			entries = append(entries, e)
+		}
+	}
+
+	// The whole file was read if tail returned fewer lines than requested.
+	lines := strings.Count(strings.TrimRight(result.Output, "\n"), "\n") + 1
+	if !q.Since.IsZero() && lines >= q.Lines && len(parsed) > 0 && parsed[0].Time.After(q.Since) {
+		return entries, &LogTruncatedError{File: q.File, Lines: q.Lines, Oldest: parsed[0].Time}
+	}
+	return entries, nil
+}
+
+// SNMP access levels of communities and users.
+const (
+	SnmpReadOnly  = "ro"
+	SnmpReadWrite = "rw"
+)
+
+// SNMP v3 protocols and security levels.
+const (
+	SnmpAuthNone = "none"
+	SnmpAuthMD5  = "md5"
+	SnmpAuthSHA  = "sha"
+
+	SnmpPrivacyNone = "none"
+	SnmpPrivacyAES  = "aes"
+	SnmpPrivacyDES  = "des"
+
+	SnmpSecurityNoAuth      = "no-auth-no-privacy"
+	SnmpSecurityAuthNoPriv  = "auth-no-privacy"
+	SnmpSecurityAuthPrivacy = "auth-privacy"
+)
+
+type snmpDTO struct {
+	SelfLink         string   `json:"selfLink,omitempty"`
+	AgentAddresses   []string `json:"agentAddresses,omitempty"`
+	AgentTrap        string   `json:"agentTrap,omitempty" bool:"enabled"`
+	AllowedAddresses []string `json:"allowedAddresses,omitempty"`
+	AuthTrap         string   `json:"authTrap,omitempty" bool:"enabled"`
+	BigipTraps       string   `json:"bigipTraps,omitempty" bool:"enabled"`
+	SysContact       string   `json:"sysContact,omitempty"`
+	SysLocation      string   `json:"sysLocation,omitempty"`
+	TrapCommunity    string   `json:"trapCommunity,omitempty"`
+	TrapSource       string   `json:"trapSource,omitempty"`
+}
+
+// SNMP contains the settings of the SNMP agent. AllowedAddresses are the
+// clients, in addition to localhost, that may query the agent, i.e.
+// "10.0.0.0/8".
+type SNMP struct {
+	SelfLink         string
+	AgentAddresses   []string
+	AgentTrap        *bool
+	AllowedAddresses []string
+	AuthTrap         *bool
+	BigipTraps       *bool
+	SysContact       string
+	SysLocation      string
+	TrapCommunity    string
+	TrapSource       string
+}
+
+func (s *SNMP) MarshalJSON() ([]byte, error) {
+	var dto snmpDTO
+	marshal(&dto, s)
+	return json.Marshal(dto)
+}
+
+func (s *SNMP) UnmarshalJSON(b []byte) error {
+	var dto snmpDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(s, &dto)
+}
+
+// SNMPCommunities contains a list of every SNMP v1/v2c community.
+type SNMPCommunities struct {
+	SNMPCommunities []SNMPCommunity `json:"items"`
+}
+
+type snmpCommunityDTO struct {
+	Name          string `json:"name,omitempty"`
+	Partition     string `json:"partition,omitempty"`
+	FullPath      string `json:"fullPath,omitempty"`
+	Access        string `json:"access,omitempty"`
+	CommunityName string `json:"communityName,omitempty"`
+	Description   string `json:"description,omitempty"`
+	Ipv6          string `json:"ipv6,omitempty" bool:"enabled"`
+	OidSubset     string `json:"oidSubset,omitempty"`
+	Source        string `json:"source,omitempty"`
+}
+
+// SNMPCommunity is an SNMP v1/v2c community. Name identifies the object,
+// CommunityName is the community string clients use. Source restricts the
+// clients, "default" allows all AllowedAddresses of the agent.
+type SNMPCommunity struct {
+	Name          string
+	Partition     string
+	FullPath      string
+	Access        string
+	CommunityName string
+	Description   string
+	Ipv6          *bool
+	OidSubset     string
+	Source        string
+}
+
+func (c *SNMPCommunity) MarshalJSON() ([]byte, error) {
+	var dto snmpCommunityDTO
+	marshal(&dto, c)
+	return json.Marshal(dto)
+}
+
+func (c *SNMPCommunity) UnmarshalJSON(b []byte) error {
+	var dto snmpCommunityDTO
+	err := json.Unmarshal(b, &dto)
+	if err != nil {
+		return err
+	}
+	return marshal(c, &dto)
+}
+
+// SNMPUsers contains a list of every SNMP v3 user.
+type SNMPUsers struct {
+	SNMPUsers []SNMPUser `json:"items"`
+}
+
+// SNMPUser is an SNMP v3 user. The passwords are only sent, the API doesn't
+// return them.
+type SNMPUser struct {
+	Name            string `json:"name,omitempty"`
+	Partition       string `json:"partition,omitempty"`
+	FullPath        string `json:"fullPath,omitempty"`
+	Access          string `json:"access,omitempty"`
+	AuthPassword    string `json:"authPassword,omitempty"`
+	AuthProtocol    string `json:"authProtocol,omitempty"`
+	Description     string `json:"description,omitempty"`
+	OidSubset       string `json:"oidSubset,omitempty"`
+	PrivacyPassword string `json:"privacyPassword,omitempty"`
+	PrivacyProtocol string `json:"privacyProtocol,omitempty"`
+	SecurityLevel   string `json:"securityLevel,omitempty"`
+	Username        string `json:"username,omitempty"`
+}
+
+// SNMPTraps contains a list of every SNMP trap destination.
+type SNMPTraps struct {
+	SNMPTraps []SNMPTrap `json:"items"`
+}
+
+// SNMPTrap is a destination for SNMP traps. Community is used by version
+// "1" and "2c", the v3 fields by version "3".
+type SNMPTrap struct {
+	Name         string `json:"name,omitempty"`
+	Partition    string `json:"partition,omitempty"`
+	FullPath     string `json:"fullPath,omitempty"`
+	AuthPassword string `json:"authPassword,omitempty"`
+	AuthProtocol string `json:"authProtocol,omitempty"`
+	Community    string `json:"community,omitempty"`
+	Description  string `json:"description,omitempty"`
+	EngineId     string `json:"engineId,omitempty"`
+	Host         string `json:"host,omitempty"`
+	// "management" sends traps from the management port, "other" from TMM.
+	Network         string `json:"network,omitempty"`
+	Port            int    `json:"port,omitempty"`
+	PrivacyPassword string `json:"privacyPassword,omitempty"`
+	PrivacyProtocol string `json:"privacyProtocol,omitempty"`
+	SecurityLevel   string `json:"securityLevel,omitempty"`
+	SecurityName    string `json:"securityName,omitempty"`
+	Version         string `json:"version,omitempty"`
+}
+
+// SNMP returns the settings of the SNMP agent.
+func (b *BigIP) SNMP() (*SNMP, error) {
+	var snmp SNMP
+	err, _ := b.getForEntity(&snmp, uriSys, uriSnmp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snmp, nil
+}
+
+// SetSNMP changes the attributes provided of the SNMP agent settings.
+func (b *BigIP) SetSNMP(config *SNMP) error {
+	return b.patch(config, uriSys, uriSnmp)
+}
+
+// SetSNMPAllowedAddresses replaces the addresses that may query the agent.
+func (b *BigIP) SetSNMPAllowedAddresses(addresses ...string) error {
+	config := struct {
+		AllowedAddresses []string `json:"allowedAddresses"`
+	}{addresses}
+	if config.AllowedAddresses == nil {
+		config.AllowedAddresses = []string{}
+	}
+
+	return b.patch(config, uriSys, uriSnmp)
+}
+
+// SNMPCommunities returns a list of SNMP communities.
+func (b *BigIP) SNMPCommunities() (*SNMPCommunities, error) {
+	var communities SNMPCommunities
+	err, _ := b.getForEntity(&communities, uriSys, uriSnmp, uriCommunity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &communities, nil
+}
+
+// GetSNMPCommunity retrieves an SNMP community by name. Returns nil if the
+// community does not exist.
+func (b *BigIP) GetSNMPCommunity(name string) (*SNMPCommunity, error) {
+	var community SNMPCommunity
+	err, ok := b.getForEntity(&community, uriSys, uriSnmp, uriCommunity, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &community, nil
+}
+
+// CreateSNMPCommunity adds a community with the community string
+// <communityName> and access SnmpReadOnly or SnmpReadWrite.
+func (b *BigIP) CreateSNMPCommunity(name, communityName, access string) error {
+	config := &SNMPCommunity{
+		Name:          name,
+		CommunityName: communityName,
+		Access:        access,
+	}
+
+	return b.post(config, uriSys, uriSnmp, uriCommunity)
+}
+
+// AddSNMPCommunity adds a community by config.
+func (b *BigIP) AddSNMPCommunity(config *SNMPCommunity) error {
+	return b.post(config, uriSys, uriSnmp, uriCommunity)
+}
+
+// DeleteSNMPCommunity removes an SNMP community.
+func (b *BigIP) DeleteSNMPCommunity(name string) error {
+	return b.delete(uriSys, uriSnmp, uriCommunity, name)
+}
+
+// ModifySNMPCommunity allows you to change any attribute of an SNMP
+// community. This replaces the existing configuration, so use
+// PatchSNMPCommunity if you want to change only particular attributes.
+func (b *BigIP) ModifySNMPCommunity(name string, config *SNMPCommunity) error {
+	return b.put(config, uriSys, uriSnmp, uriCommunity, name)
+}
+
+// PatchSNMPCommunity changes only the attributes provided of an SNMP
+// community.
+func (b *BigIP) PatchSNMPCommunity(name string, config *SNMPCommunity) error {
+	return b.patch(config, uriSys, uriSnmp, uriCommunity, name)
+}
+
+// SNMPUsers returns a list of SNMP v3 users.
+func (b *BigIP) SNMPUsers() (*SNMPUsers, error) {
+	var users SNMPUsers
+	err, _ := b.getForEntity(&users, uriSys, uriSnmp, uriSnmpUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &users, nil
+}
+
+// GetSNMPUser retrieves an SNMP v3 user by name. Returns nil if the user does
+// not exist.
+func (b *BigIP) GetSNMPUser(name string) (*SNMPUser, error) {
+	var user SNMPUser
+	err, ok := b.getForEntity(&user, uriSys, uriSnmp, uriSnmpUser, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &user, nil
+}
+
+// CreateSNMPUser adds a read only SNMP v3 user authenticated with SHA and
+// encrypted with AES.
+func (b *BigIP) CreateSNMPUser(name, authPassword, privacyPassword string) error {
+	config := &SNMPUser{
+		Name:            name,
+		Username:        name,
+		Access:          SnmpReadOnly,
+		AuthProtocol:    SnmpAuthSHA,
+		AuthPassword:    authPassword,
+		PrivacyProtocol: SnmpPrivacyAES,
+		PrivacyPassword: privacyPassword,
+	}
+
+	return b.post(config, uriSys, uriSnmp, uriSnmpUser)
+}
+
+// AddSNMPUser adds an SNMP v3 user by config.
+func (b *BigIP) AddSNMPUser(config *SNMPUser) error {
+	return b.post(config, uriSys, uriSnmp, uriSnmpUser)
+}
+
+// DeleteSNMPUser removes an SNMP v3 user.
+func (b *BigIP) DeleteSNMPUser(name string) error {
+	return b.delete(uriSys, uriSnmp, uriSnmpUser, name)
+}
+
+// ModifySNMPUser allows you to change any attribute of an SNMP v3 user. This
+// replaces the existing configuration, so use PatchSNMPUser if you want to
+// change only particular attributes.
+func (b *BigIP) ModifySNMPUser(name string, config *SNMPUser) error {
+	return b.put(config, uriSys, uriSnmp, uriSnmpUser, name)
+}
+
+// PatchSNMPUser changes only the attributes provided of an SNMP v3 user.
+func (b *BigIP) PatchSNMPUser(name string, config *SNMPUser) error {
+	return b.patch(config, uriSys, uriSnmp, uriSnmpUser, name)
+}
+
+// SNMPTraps returns a list of SNMP trap destinations.
+func (b *BigIP) SNMPTraps() (*SNMPTraps, error) {
+	var traps SNMPTraps
+	err, _ := b.getForEntity(&traps, uriSys, uriSnmp, uriTrap)
+	if err != nil {
+		return nil, err
+	}
+
+	return &traps, nil
+}
+
+// GetSNMPTrap retrieves an SNMP trap destination by name. Returns nil if the
+// trap destination does not exist.
+func (b *BigIP) GetSNMPTrap(name string) (*SNMPTrap, error) {
+	var trap SNMPTrap
+	err, ok := b.getForEntity(&trap, uriSys, uriSnmp, uriTrap, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &trap, nil
+}
+
+// CreateSNMPTrap adds a v2c trap destination sending to <host>:<port> with
+// community <community>.
+func (b *BigIP) CreateSNMPTrap(name, host string, port int, community string) error {
+	config := &SNMPTrap{
+		Name:      name,
+		Host:      host,
+		Port:      port,
+		Community: community,
+		Version:   "2c",
+	}
+
+	return b.post(config, uriSys, uriSnmp, uriTrap)
+}
+
+// AddSNMPTrap adds an SNMP trap destination by config.
+func (b *BigIP) AddSNMPTrap(config *SNMPTrap) error {
+	return b.post(config, uriSys, uriSnmp, uriTrap)
+}
+
+// DeleteSNMPTrap removes an SNMP trap destination.
+func (b *BigIP) DeleteSNMPTrap(name string) error {
+	return b.delete(uriSys, uriSnmp, uriTrap, name)
+}
+
+// ModifySNMPTrap allows you to change any attribute of an SNMP trap
+// destination. This replaces the existing configuration, so use PatchSNMPTrap
+// if you want to change only particular attributes.
+func (b *BigIP) ModifySNMPTrap(name string, config *SNMPTrap) error {
+	return b.put(config, uriSys, uriSnmp, uriTrap, name)
+}
+
+// PatchSNMPTrap changes only the attributes provided of an SNMP trap
+// destination.
+func (b *BigIP) PatchSNMPTrap(name string, config *SNMPTrap) error {
+	return b.patch(config, uriSys, uriSnmp, uriTrap, name)
+}
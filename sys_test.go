@@ -1,12 +1,15 @@
 package bigip
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -479,3 +482,1227 @@ func (s *SysTestSuite) TestLodSysConfig() {
 	err = s.Client.LoadSysConfig("backup.tar", "secret-key")
 	assert.Nil(s.T(), err)
 }
+
+func (s *SysTestSuite) TestUcsArchives() {
+	resp := `{
+  "kind": "tm:sys:ucs:ucscollectionstate",
+  "selfLink": "https://localhost/mgmt/tm/sys/ucs?ver=13.1.1",
+  "items": [
+    {
+      "kind": "tm:sys:ucs:ucsstate",
+      "generation": 0,
+      "apiRawValues": {
+        "base_build": "0.0.4",
+        "build": "0.0.4",
+        "built": "180314165121",
+        "changeset": "fcf4c9b",
+        "edition": "Final",
+        "encrypted": "no",
+        "file_created_date": "2020-04-15T10:12:03Z",
+        "file_size": "223232 (in bytes)",
+        "filename": "/var/local/ucs/backup.ucs",
+        "hostname": "bigip1.example.com",
+        "install_date": "Wed Mar 14 19:06:06 PDT 2018",
+        "product": "BIG-IP",
+        "version": "13.1.1"
+      }
+    }
+  ]
+}`
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(resp))
+	}
+
+	archives, err := s.Client.UcsArchives()
+
+	require.Nil(s.T(), err, "Error loading UCS archives")
+	assert.Equal(s.T(), fmt.Sprintf("/mgmt/tm/%s/%s", uriSys, uriUcs), s.LastRequest.URL.Path, "Wrong uri to fetch UCS archives")
+	require.Equal(s.T(), 1, len(archives.UcsArchives))
+	archive := archives.UcsArchives[0]
+	assert.Equal(s.T(), "backup.ucs", archive.Name)
+	assert.Equal(s.T(), "/var/local/ucs/backup.ucs", archive.Path)
+	assert.Equal(s.T(), int64(223232), archive.Size)
+	assert.Equal(s.T(), time.Date(2020, 4, 15, 10, 12, 3, 0, time.UTC), archive.Created)
+	assert.Equal(s.T(), "13.1.1", archive.Version)
+	assert.False(s.T(), archive.Encrypted)
+}
+
+// serveTask answers the requests made while running an asynchronous task,
+// recording the body used to create it.
+func (s *SysTestSuite) serveTask(created *string, finalState string) {
+	polls := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST":
+			*created = s.LastRequestBody
+			w.Write([]byte(`{"_taskId": "1234", "_taskState": "CREATED"}`))
+		case r.Method == "PUT":
+			assert.JSONEq(s.T(), `{"_taskState": "VALIDATING"}`, s.LastRequestBody)
+			w.Write([]byte(`{"_taskId": "1234", "_taskState": "VALIDATING"}`))
+		case r.URL.Path == "/mgmt/tm/task/sys/ucs/1234/result":
+			w.Write([]byte(`{"_taskId": "1234", "_taskState": "FAILED", "_taskResultMessage": "bad passphrase"}`))
+		default:
+			polls++
+			state := "VALIDATING"
+			if polls > 1 {
+				state = finalState
+			}
+			w.Write([]byte(`{"_taskId": "1234", "_taskState": "` + state + `"}`))
+		}
+	}
+}
+
+func (s *SysTestSuite) TestCreateUcs() {
+	defer func(d time.Duration) { taskPollInterval = d }(taskPollInterval)
+	taskPollInterval = time.Millisecond
+	var created string
+	s.serveTask(&created, "COMPLETED")
+
+	err := s.Client.CreateUcs("backup.ucs", "secret", 10*time.Second)
+
+	require.Nil(s.T(), err, "Error creating UCS archive")
+	assert.Equal(s.T(), "/mgmt/tm/task/sys/ucs/1234", s.LastRequest.URL.Path, "Wrong uri to poll task")
+	assert.JSONEq(s.T(), `{"command": "save", "name": "backup.ucs", "options": [{"passphrase": "secret"}]}`, created)
+}
+
+func (s *SysTestSuite) TestRestoreUcs() {
+	defer func(d time.Duration) { taskPollInterval = d }(taskPollInterval)
+	taskPollInterval = time.Millisecond
+	var created string
+	s.serveTask(&created, "FAILED")
+
+	err := s.Client.RestoreUcs("backup.ucs", &UcsRestoreOptions{NoLicense: true, NoPlatformCheck: true, Passphrase: "secret"}, 10*time.Second)
+
+	require.Error(s.T(), err)
+	assert.Equal(s.T(), "Task 1234 failed: bad passphrase", err.Error())
+	assert.JSONEq(s.T(), `{"command": "load", "name": "backup.ucs", "options": [{"no-license": ""}, {"no-platform-check": ""}, {"passphrase": "secret"}]}`, created)
+}
+
+func (s *SysTestSuite) TestDeleteUcs() {
+	err := s.Client.DeleteUcs("backup.ucs")
+
+	require.Nil(s.T(), err, "Error deleting UCS archive")
+	assert.Equal(s.T(), fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriSys, uriUcs, "backup.ucs"), s.LastRequest.URL.Path, "Wrong uri to delete UCS archive")
+	assert.Equal(s.T(), "DELETE", s.LastRequest.Method)
+}
+
+func (s *SysTestSuite) TestUploadUcs() {
+	content := []byte("ucs content")
+	var paths []string
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{}`))
+	}
+
+	_, err := s.Client.UploadUcs(bytes.NewReader(content), int64(len(content)), "backup.ucs")
+
+	require.Nil(s.T(), err, "Error uploading UCS archive")
+	assert.Equal(s.T(), []string{"/mgmt/shared/file-transfer/uploads/backup.ucs", "/mgmt/tm/util/unix-mv"}, paths)
+	assert.JSONEq(s.T(), `{"command": "run", "utilCmdArgs": "/var/config/rest/downloads/backup.ucs /var/local/ucs/backup.ucs"}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestSoftwareImages() {
+	resp := `{
+  "kind": "tm:sys:software:image:imagecollectionstate",
+  "items": [
+    {
+      "kind": "tm:sys:software:image:imagestate",
+      "name": "BIGIP-13.1.1-0.0.4.iso",
+      "fullPath": "BIGIP-13.1.1-0.0.4.iso",
+      "generation": 1,
+      "build": "0.0.4",
+      "buildDate": "Wed Mar 14 16 51 21 PDT 2018",
+      "checksum": "a2fa0ad9a4d1ab4a9fc6d8bb30e5d6c8",
+      "fileSize": "2000 MB",
+      "lastModified": "Thu Apr 16 10:12:03 2020",
+      "product": "BIG-IP",
+      "verified": "yes",
+      "version": "13.1.1"
+    }
+  ]
+}`
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(resp))
+	}
+
+	images, err := s.Client.SoftwareImages()
+
+	require.Nil(s.T(), err, "Error loading software images")
+	assert.Equal(s.T(), fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriSys, uriSoftware, uriImage), s.LastRequest.URL.Path, "Wrong uri to fetch software images")
+	require.Equal(s.T(), 1, len(images.SoftwareImages))
+	assert.Equal(s.T(), "BIGIP-13.1.1-0.0.4.iso", images.SoftwareImages[0].Name)
+	assert.Equal(s.T(), "13.1.1", images.SoftwareImages[0].Version)
+}
+
+func (s *SysTestSuite) TestInstallSoftwareImage() {
+	err := s.Client.InstallSoftwareImage("BIGIP-13.1.1-0.0.4.iso", "HD1.2", true)
+
+	require.Nil(s.T(), err, "Error installing software image")
+	assert.Equal(s.T(), fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriSys, uriSoftware, uriImage), s.LastRequest.URL.Path, "Wrong uri to install software image")
+	assert.Equal(s.T(), "POST", s.LastRequest.Method)
+	assert.JSONEq(s.T(), `{"command": "install", "name": "BIGIP-13.1.1-0.0.4.iso", "volume": "HD1.2", "options": [{"create-volume": ""}]}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestInstallHotfix() {
+	err := s.Client.InstallHotfix("Hotfix-BIGIP-13.1.1.2-0.0.4-ENG.iso", "HD1.2", false)
+
+	require.Nil(s.T(), err, "Error installing hotfix")
+	assert.Equal(s.T(), fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriSys, uriSoftware, uriHotfix), s.LastRequest.URL.Path, "Wrong uri to install hotfix")
+	assert.JSONEq(s.T(), `{"command": "install", "name": "Hotfix-BIGIP-13.1.1.2-0.0.4-ENG.iso", "volume": "HD1.2"}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestWaitForVolumeInstall() {
+	defer func(d time.Duration) { taskPollInterval = d }(taskPollInterval)
+	taskPollInterval = time.Millisecond
+	statuses := []string{"installing 10.000 pct", "installing 55.000 pct", "complete"}
+	polls := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "HD1.2", "version": "13.1.1", "status": "` + statuses[polls] + `"}`))
+		polls++
+	}
+
+	var progress []float64
+	err := s.Client.WaitForVolumeInstall("HD1.2", 10*time.Second, func(v *Volume) {
+		progress = append(progress, v.InstallPercent())
+	})
+
+	require.Nil(s.T(), err, "Error waiting for volume install")
+	assert.Equal(s.T(), fmt.Sprintf("/mgmt/tm/%s/%s/%s/%s", uriSys, uriSoftware, uriVolume, "HD1.2"), s.LastRequest.URL.Path, "Wrong uri to fetch volume")
+	assert.Equal(s.T(), []float64{10, 55, 100}, progress)
+}
+
+func (s *SysTestSuite) TestWaitForVolumeInstallFailed() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "HD1.2", "status": "failed (Failed to mount volume)"}`))
+	}
+
+	err := s.Client.WaitForVolumeInstall("HD1.2", 10*time.Second, nil)
+
+	require.Error(s.T(), err)
+	assert.Equal(s.T(), "Installation to HD1.2 failed: failed (Failed to mount volume)", err.Error())
+}
+
+func (s *SysTestSuite) TestDeleteVolume() {
+	err := s.Client.DeleteVolume("HD1.1")
+
+	require.Nil(s.T(), err, "Error deleting volume")
+	assert.Equal(s.T(), fmt.Sprintf("/mgmt/tm/%s/%s/%s/%s", uriSys, uriSoftware, uriVolume, "HD1.1"), s.LastRequest.URL.Path, "Wrong uri to delete volume")
+	assert.Equal(s.T(), "DELETE", s.LastRequest.Method)
+}
+
+func (s *SysTestSuite) TestSetBootVolume() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"commandResult": "\n` + bashExitMarker + `0"}`))
+	}
+
+	err := s.Client.SetBootVolume("HD1.2")
+
+	require.Nil(s.T(), err, "Error setting boot volume")
+	assert.Contains(s.T(), s.LastRequestBody, "(switchboot -b HD1.2)")
+}
+
+func (s *SysTestSuite) TestReboot() {
+	err := s.Client.RebootToVolume("HD1.2")
+
+	require.Nil(s.T(), err, "Error rebooting")
+	assert.Equal(s.T(), fmt.Sprintf("/mgmt/tm/%s", uriSys), s.LastRequest.URL.Path, "Wrong uri to reboot")
+	assert.Equal(s.T(), "POST", s.LastRequest.Method)
+	assert.JSONEq(s.T(), `{"command": "reboot", "volume": "HD1.2"}`, s.LastRequestBody)
+}
+
+// serveReadiness answers readiness checks. The device is up after <bootPolls>
+// requests to the echo endpoint.
+func (s *SysTestSuite) serveReadiness(bootPolls int) {
+	echoes := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mgmt/shared/echo":
+			echoes++
+			if echoes <= bootPolls {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte("restjavad is starting"))
+				return
+			}
+			w.Write([]byte(`{"stage": "STARTED"}`))
+		case "/mgmt/tm/sys/mcp-state":
+			w.Write([]byte(`{"kind": "tm:sys:mcp-state:mcp-statestats", "entries": {"https://localhost/mgmt/tm/sys/mcp-state/0": {"nestedStats": {"entries": {"phase": {"description": "running"}, "endDatetime": {"description": "2020-04-15T10:12:03Z"}}}}}}`))
+		case "/mgmt/tm/sys/ready":
+			w.Write([]byte(`{"kind": "tm:sys:ready:readystats", "entries": {"https://localhost/mgmt/tm/sys/ready/0": {"nestedStats": {"entries": {"configReady": {"description": "yes"}, "licenseReady": {"description": "yes"}, "provisionReady": {"description": "yes"}}}}}}`))
+		case "/mgmt/tm/cm/device":
+			w.Write([]byte(`{"items": [{"name": "bigip1", "failoverState": "active", "selfDevice": "true"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func (s *SysTestSuite) TestWaitUntilReady() {
+	defer func(d time.Duration) { readyPollInterval = d }(readyPollInterval)
+	readyPollInterval = time.Millisecond
+	s.serveReadiness(1)
+
+	var statuses []*ReadyStatus
+	err := s.Client.WaitUntilReady(10*time.Second, func(status *ReadyStatus) {
+		statuses = append(statuses, status)
+	})
+
+	require.Nil(s.T(), err, "Error waiting for system to be ready")
+	require.Equal(s.T(), 2, len(statuses))
+	assert.False(s.T(), statuses[0].RestAPI)
+	assert.Error(s.T(), statuses[0].Err)
+	assert.Equal(s.T(), &ReadyStatus{RestAPI: true, McpdRunning: true, ConfigReady: true, LicenseReady: true, ProvisionReady: true, FailoverState: "active"}, statuses[1])
+}
+
+func (s *SysTestSuite) TestWaitUntilReadyTimeout() {
+	defer func(d time.Duration) { readyPollInterval = d }(readyPollInterval)
+	readyPollInterval = time.Millisecond
+	s.serveReadiness(1000000)
+
+	err := s.Client.WaitUntilReady(20*time.Millisecond, nil)
+
+	require.Error(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "restjavad is starting")
+}
+
+func (s *SysTestSuite) TestManagementIPs() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+  "kind": "tm:sys:management-ip:management-ipcollectionstate",
+  "items": [{"kind": "tm:sys:management-ip:management-ipstate", "name": "192.168.1.245/24", "fullPath": "192.168.1.245/24", "generation": 1}]
+}`))
+	}
+
+	ips, err := s.Client.ManagementIPs()
+
+	s.Require().Nil(err, "Error getting management IPs")
+	s.Require().Equal([]ManagementIPAddress{{Name: "192.168.1.245/24", FullPath: "192.168.1.245/24", Generation: 1}}, ips.Addresses)
+}
+
+func (s *SysTestSuite) TestCreateManagementIP() {
+	err := s.Client.CreateManagementIP("192.168.1.246/24")
+
+	s.Require().Nil(err, "Error creating management IP")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriSys, uriManagementIp), s.LastRequest.URL.Path, "Wrong uri to create management IP")
+	s.Require().JSONEq(`{"name": "192.168.1.246/24"}`, s.LastRequestBody)
+
+	err = s.Client.DeleteManagementIP("192.168.1.245/24")
+
+	s.Require().Nil(err, "Error deleting management IP")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriSys, uriManagementIp, "192.168.1.245~24"), s.LastRequest.URL.Path, "Wrong uri to delete management IP")
+	s.Require().Equal("DELETE", s.LastRequest.Method)
+}
+
+func (s *SysTestSuite) TestNTP() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"kind": "tm:sys:ntp:ntpstate", "servers": ["0.pool.ntp.org", "1.pool.ntp.org"], "timezone": "UTC"}`))
+	}
+
+	ntp, err := s.Client.NTP()
+
+	s.Require().Nil(err, "Error getting NTP configuration")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriSys, uriNtp), s.LastRequest.URL.Path)
+	s.Require().Equal([]string{"0.pool.ntp.org", "1.pool.ntp.org"}, ntp.Servers)
+	s.Require().Equal("UTC", ntp.Timezone)
+
+	err = s.Client.SetNTP(NTP{Servers: []string{"10.1.1.123"}, Timezone: "Europe/Berlin"})
+
+	s.Require().Nil(err, "Error setting NTP configuration")
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"servers": ["10.1.1.123"], "timezone": "Europe/Berlin"}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestSetDNS() {
+	err := s.Client.SetDNS(DNS{NameServers: []string{"10.1.1.53", "10.1.2.53"}, Search: []string{"example.com"}})
+
+	s.Require().Nil(err, "Error setting DNS configuration")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriSys, uriDns), s.LastRequest.URL.Path)
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"nameServers": ["10.1.1.53", "10.1.2.53"], "search": ["example.com"]}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestClearDNSSearch() {
+	err := s.Client.SetDNS(DNS{Search: []string{}})
+
+	s.Require().Nil(err, "Error clearing DNS search domains")
+	s.Require().JSONEq(`{"search": []}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestClearNTPServers() {
+	err := s.Client.SetNTP(NTP{Servers: []string{}})
+
+	s.Require().Nil(err, "Error clearing NTP servers")
+	s.Require().JSONEq(`{"servers": []}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestGlobalSettings() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"kind": "tm:sys:global-settings:global-settingsstate", "guiSetup": "enabled", "hostname": "bigip1.example.com", "mgmtDhcp": "disabled", "consoleInactivityTimeout": 0}`))
+	}
+
+	settings, err := s.Client.GlobalSettings()
+
+	s.Require().Nil(err, "Error getting global settings")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriSys, uriGlobalSettings), s.LastRequest.URL.Path)
+	s.Require().Equal("bigip1.example.com", settings.Hostname)
+	s.Require().True(*settings.GuiSetup)
+	s.Require().False(*settings.MgmtDhcp)
+
+	err = s.Client.SetGlobalSettings(&GlobalSettings{Hostname: "bigip2.example.com", GuiSetup: Bool(false)})
+
+	s.Require().Nil(err, "Error setting global settings")
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"hostname": "bigip2.example.com", "guiSetup": "disabled"}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestCreateManagementRoute() {
+	err := s.Client.CreateManagementRoute("default", "default", "192.168.1.254")
+
+	s.Require().Nil(err, "Error creating management route")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriSys, uriMgmtRoute), s.LastRequest.URL.Path, "Wrong uri to create management route")
+	s.Require().JSONEq(`{"name": "default", "network": "default", "gateway": "192.168.1.254"}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) serveProvisioning(memory string, activeModules string) {
+	s.serveReadiness(0)
+	readiness := s.ResponseFunc
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mgmt/tm/sys/provision":
+			w.Write([]byte(`{"items": [
+  {"name": "afm", "level": "none"},
+  {"name": "apm", "level": "none"},
+  {"name": "asm", "level": "none"},
+  {"name": "avr", "level": "nominal"},
+  {"name": "gtm", "level": "none"},
+  {"name": "ltm", "level": "nominal", "cpuRatio": 0, "diskRatio": 0, "memoryRatio": 0}
+]}`))
+		case "/mgmt/tm/shared/licensing/registration":
+			w.Write([]byte(`{"registrationKey": "ABCDE-FGHIJ", "activeModules": [` + activeModules + `]}`))
+		case "/mgmt/tm/sys/host-info":
+			w.Write([]byte(`{"kind": "tm:sys:host-info:host-infostats", "entries": {"https://localhost/mgmt/tm/sys/host-info/0": {"nestedStats": {"entries": {"cpuCount": {"value": 4}, "memoryTotal": {"value": ` + memory + `}, "memoryUsed": {"value": 3000000000}}}}}}`))
+		default:
+			readiness(w, r)
+		}
+	}
+}
+
+func (s *SysTestSuite) TestValidateProvisioning() {
+	s.serveProvisioning("8365150208", `"Best Bundle, VE-1G|ABCDEFG|Local Traffic Manager, VE|Application Security Manager, VE"`)
+
+	err := s.Client.ValidateProvisioning(map[string]string{"asm": ProvisionNominal, "avr": ProvisionNone})
+	s.Require().Nil(err, "Valid provisioning rejected")
+
+	err = s.Client.ValidateProvisioning(map[string]string{"asm": ProvisionNominal, "afm": ProvisionNominal, "ltm": ProvisionDedicated, "sam": ProvisionNominal})
+	s.Require().Error(err)
+	s.Require().Equal("Invalid provisioning: 4 modules need 16384 MB of memory, the system has 7977 MB; a dedicated module can't be provisioned with others: afm, asm, avr, ltm; module afm is not licensed; module sam does not exist", err.Error())
+}
+
+func (s *SysTestSuite) TestModuleLicensedExactNames() {
+	license := &LicenseState{ActiveModules: []string{"Best Bundle, VE-1G|ABCDEFG|Local Traffic Manager, VE|DNSSEC"}}
+
+	s.Require().True(moduleLicensed(license, "ltm"))
+	s.Require().False(moduleLicensed(license, "gtm"), "DNSSEC licensed gtm")
+
+	license.ActiveModules = append(license.ActiveModules, "DNS, VE|HIJKLMN")
+	s.Require().True(moduleLicensed(license, "gtm"))
+}
+
+func (s *SysTestSuite) TestModuleLicensedNames() {
+	cases := []struct {
+		module   string
+		active   string
+		licensed bool
+	}{
+		{"apm", "Local Traffic Manager, VE-10G|KEY|APM, Limited", true},
+		{"apm", "APM, Base, VE (50 CCU / 200 AS)|KEY", true},
+		{"apm", "Local Traffic Manager, VE|KEY|Access Policy Manager, VE", true},
+		{"apm", "Local Traffic Manager, VE|KEY|APMX", false},
+		{"gtm", "DNS and GTM (250 QPS), VE|KEY", true},
+		{"gtm", "Local Traffic Manager, VE|KEY|DNS Services", true},
+		{"gtm", "Local Traffic Manager, VE|KEY|Global Traffic Manager, VE", true},
+		{"gtm", "Local Traffic Manager, VE|KEY|DNSSEC", false},
+		{"afm", "Local Traffic Manager, VE|KEY|APM, Limited", false},
+	}
+	for _, c := range cases {
+		license := &LicenseState{ActiveModules: []string{c.active}}
+		s.Require().Equal(c.licensed, moduleLicensed(license, c.module), "%s in %q", c.module, c.active)
+	}
+
+	license := &LicenseState{FeatureFlags: []LicenseFeatureFlag{{FeatureName: "mod_asm", FeatureValue: "enabled"}}}
+	s.Require().True(moduleLicensed(license, "asm"), "mod_asm feature flag ignored")
+}
+
+func (s *SysTestSuite) TestValidateProvisioningLicenseNames() {
+	s.serveProvisioning("17179869184", `"Local Traffic Manager, VE-10G|KEY|APM, Limited|DNS and GTM (250 QPS), VE"`)
+
+	err := s.Client.ValidateProvisioning(map[string]string{"apm": ProvisionNominal, "gtm": ProvisionNominal, "avr": ProvisionNone})
+
+	s.Require().Nil(err, "Licensed modules rejected")
+}
+
+func (s *SysTestSuite) TestValidateProvisioningLevel() {
+	s.serveProvisioning("8365150208", `"Best Bundle, VE-1G|ABCDEFG|Local Traffic Manager, VE|Application Security Manager, VE"`)
+
+	err := s.Client.SetProvisioning(map[string]string{"asm": "nominal } ltm { level none"}, 10*time.Second, nil)
+
+	s.Require().Error(err)
+	s.Require().Equal(`Invalid provisioning: invalid level "nominal } ltm { level none" for module asm`, err.Error())
+	s.Require().NotEqual("/mgmt/tm/util/bash", s.LastRequest.URL.Path, "Ran tmsh with an invalid level")
+}
+
+func (s *SysTestSuite) TestSetProvisioning() {
+	defer func(d time.Duration) { readyPollInterval = d }(readyPollInterval)
+	readyPollInterval = time.Millisecond
+	defer func(d time.Duration) { provisionRestartGrace = d }(provisionRestartGrace)
+	provisionRestartGrace = 10 * time.Millisecond
+	s.serveProvisioning("8365150208", `"Local Traffic Manager, VE|Application Security Manager, VE"`)
+	provisioning := s.ResponseFunc
+	var command string
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mgmt/tm/util/bash" {
+			command = s.LastRequestBody
+			w.Write([]byte(`{"command": "run", "commandResult": "\n` + bashExitMarker + `0"}`))
+			return
+		}
+		provisioning(w, r)
+	}
+
+	err := s.Client.SetProvisioning(map[string]string{"avr": ProvisionNone, "asm": ProvisionNominal}, 10*time.Second, nil)
+
+	s.Require().Nil(err, "Error changing provisioning")
+	s.Require().Contains(command, "modify sys provision asm { level nominal } avr { level none }")
+	s.Require().Equal("/mgmt/tm/cm/device", s.LastRequest.URL.Path, "Did not wait until the system is ready")
+}
+
+func (s *SysTestSuite) TestSetProvisioningWaitsForRestart() {
+	defer func(d time.Duration) { readyPollInterval = d }(readyPollInterval)
+	readyPollInterval = time.Millisecond
+	s.serveProvisioning("8365150208", `"Local Traffic Manager, VE|Application Security Manager, VE"`)
+	provisioning := s.ResponseFunc
+	changed := false
+	mcpPolls := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/mgmt/tm/util/bash":
+			changed = true
+			w.Write([]byte(`{"command": "run", "commandResult": "\n` + bashExitMarker + `0"}`))
+		case r.URL.Path == "/mgmt/tm/sys/mcp-state" && changed:
+			// Ready once more before mcpd restarts, then down twice.
+			mcpPolls++
+			if mcpPolls == 2 || mcpPolls == 3 {
+				w.Write([]byte(`{"entries": {"https://localhost/mgmt/tm/sys/mcp-state/0": {"nestedStats": {"entries": {"phase": {"description": "starting"}}}}}}`))
+				return
+			}
+			provisioning(w, r)
+		default:
+			provisioning(w, r)
+		}
+	}
+
+	var statuses []bool
+	err := s.Client.SetProvisioning(map[string]string{"avr": ProvisionNone, "asm": ProvisionNominal}, 10*time.Second, func(status *ReadyStatus) {
+		statuses = append(statuses, status.Ready())
+	})
+
+	s.Require().Nil(err, "Error changing provisioning")
+	s.Require().Equal([]bool{true, false, false, true}, statuses)
+}
+
+func (s *SysTestSuite) TestProvisionModule() {
+	err := s.Client.ProvisionModule("gtm", ProvisionMinimum)
+
+	s.Require().Nil(err, "Error provisioning module")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriSys, uriProvision, "gtm"), s.LastRequest.URL.Path)
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"level": "minimum"}`, s.LastRequestBody)
+}
+
+const dbVariables = `{
+  "kind": "tm:sys:db:dbcollectionstate",
+  "items": [
+    {"name": "config.allow.rfc3927", "fullPath": "config.allow.rfc3927", "defaultValue": "disable", "scfConfig": "true", "value": "enable", "valueRange": "enable disable"},
+    {"name": "tm.tcpprogressive", "fullPath": "tm.tcpprogressive", "defaultValue": "disable", "scfConfig": "true", "value": "disable", "valueRange": "enable disable"},
+    {"name": "ui.advisory.enabled", "fullPath": "ui.advisory.enabled", "defaultValue": "false", "scfConfig": "true", "value": "false", "valueRange": "false true"}
+  ]
+}`
+
+func (s *SysTestSuite) TestNonDefaultDbVariables() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(dbVariables))
+	}
+
+	variables, err := s.Client.NonDefaultDbVariables()
+
+	s.Require().Nil(err, "Error listing db variables")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriSys, uriDb), s.LastRequest.URL.Path)
+	s.Require().Len(variables, 1)
+	s.Require().Equal("config.allow.rfc3927", variables[0].Name)
+}
+
+func (s *SysTestSuite) TestSetDbVariable() {
+	err := s.Client.SetDbVariable("ui.advisory.enabled", "true")
+
+	s.Require().Nil(err, "Error setting db variable")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s/%s", uriSys, uriDb, "ui.advisory.enabled"), s.LastRequest.URL.Path)
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"value": "true"}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestResetDbVariable() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(`{"name": "config.allow.rfc3927", "defaultValue": "disable", "value": "enable"}`))
+		}
+	}
+
+	err := s.Client.ResetDbVariable("config.allow.rfc3927")
+
+	s.Require().Nil(err, "Error resetting db variable")
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"value": "disable"}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestSetDbVariableEmpty() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(`{"name": "ui.advisory.text", "defaultValue": "", "value": "Lab system"}`))
+		}
+	}
+
+	err := s.Client.SetDbVariable("ui.advisory.text", "")
+	s.Require().Nil(err, "Error setting db variable")
+	s.Require().JSONEq(`{"value": ""}`, s.LastRequestBody)
+
+	err = s.Client.ResetDbVariable("ui.advisory.text")
+	s.Require().Nil(err, "Error resetting db variable")
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"value": ""}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestSetDbVariables() {
+	var patched []string
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			patched = append(patched, r.URL.Path+" "+s.LastRequestBody)
+			return
+		}
+		w.Write([]byte(dbVariables))
+	}
+
+	changed, err := s.Client.SetDbVariables(map[string]string{
+		"ui.advisory.enabled":  "true",
+		"tm.tcpprogressive":    "enable",
+		"config.allow.rfc3927": "enable",
+	})
+
+	s.Require().Nil(err, "Error setting db variables")
+	s.Require().Equal([]string{"tm.tcpprogressive", "ui.advisory.enabled"}, changed)
+	s.Require().Equal([]string{
+		`/mgmt/tm/sys/db/tm.tcpprogressive {"value":"enable"}`,
+		`/mgmt/tm/sys/db/ui.advisory.enabled {"value":"true"}`,
+	}, patched)
+
+	_, err = s.Client.SetDbVariables(map[string]string{"no.such.variable": "1"})
+	s.Require().Error(err)
+}
+
+func (s *SysTestSuite) TestSetDbVariablesIgnoresCase() {
+	var patched []string
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			patched = append(patched, r.URL.Path)
+			return
+		}
+		w.Write([]byte(dbVariables))
+	}
+
+	changed, err := s.Client.SetDbVariables(map[string]string{
+		"config.allow.rfc3927": "Enable",
+		"tm.tcpprogressive":    "DISABLE",
+	})
+
+	s.Require().Nil(err, "Error setting db variables")
+	s.Require().Empty(changed)
+	s.Require().Empty(patched)
+	s.Require().True((&DbVariable{Value: "Disable", DefaultValue: "disable"}).IsDefault())
+}
+
+func (s *SysTestSuite) TestGetSystemVersion() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sysVersion))
+	}
+
+	version, err := s.Client.GetSystemVersion()
+
+	s.Require().Nil(err, "Error getting system version")
+	s.Require().Equal("/mgmt/tm/sys/version", s.LastRequest.URL.Path)
+	s.Require().Equal(&SystemVersion{
+		Product: "BIG-IP",
+		Title:   "Main Package",
+		Version: "14.1.2.3",
+		Build:   "0.0.5",
+		Edition: "Point Release 3",
+		Date:    "Fri Jan 10 13:28:41 PST 2020",
+	}, version)
+}
+
+const sysVersion = `{
+	"entries": {
+		"https://localhost/mgmt/tm/sys/version/0": {
+			"nestedStats": {
+				"entries": {
+					"Build": {"description": "0.0.5"},
+					"Date": {"description": "Fri Jan 10 13:28:41 PST 2020"},
+					"Edition": {"description": "Point Release 3"},
+					"Product": {"description": "BIG-IP"},
+					"Title": {"description": "Main Package"},
+					"Version": {"description": "14.1.2.3"}
+				}
+			}
+		}
+	}
+}`
+
+func (s *SysTestSuite) TestGetInventory() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mgmt/tm/sys/hardware":
+			w.Write([]byte(`{
+				"entries": {
+					"https://localhost/mgmt/tm/sys/hardware/platform": {
+						"nestedStats": {
+							"entries": {
+								"https://localhost/mgmt/tm/sys/hardware/platform/0": {
+									"nestedStats": {
+										"entries": {
+											"baseMac": {"description": "00:94:a1:01:02:03"},
+											"marketingName": {"description": "BIG-IP i5800"}
+										}
+									}
+								}
+							}
+						}
+					},
+					"https://localhost/mgmt/tm/sys/hardware/system-info": {
+						"nestedStats": {
+							"entries": {
+								"https://localhost/mgmt/tm/sys/hardware/system-info/0": {
+									"nestedStats": {
+										"entries": {
+											"bigipChassisSerialNum": {"description": "f5-abcd-efgh"},
+											"platform": {"description": "C119"},
+											"product": {"description": "BIG-IP"}
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}`))
+		case "/mgmt/tm/sys/global-settings":
+			w.Write([]byte(`{"hostname": "bigip1.example.com"}`))
+		case "/mgmt/tm/cm/device":
+			w.Write([]byte(`{"items": [{"name": "bigip1.example.com", "selfDevice": "true", "chassisId": "f5-abcd-efgh"}]}`))
+		case "/mgmt/tm/sys/version":
+			w.Write([]byte(sysVersion))
+		case "/mgmt/tm/sys/host-info":
+			w.Write([]byte(`{
+				"entries": {
+					"https://localhost/mgmt/tm/sys/host-info/0": {
+						"nestedStats": {
+							"entries": {
+								"cpuCount": {"value": 8},
+								"memoryTotal": {"value": 33421996032}
+							}
+						}
+					}
+				}
+			}`))
+		case "/mgmt/tm/sys/disk/logical-disk":
+			w.Write([]byte(`{"items": [{"name": "HD1", "mode": "mixed", "size": 476940, "vgFree": 314932, "vgInUse": 162008, "vgReserved": 30720}]}`))
+		case "/mgmt/tm/sys/cluster/default/members":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code": 404, "message": "01020036:3: The requested cluster (default) was not found."}`))
+		case "/mgmt/tm/net/interface":
+			w.Write([]byte(`{"items": [{"name": "1.1", "enabled": true, "macAddress": "00:94:a1:01:02:04"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	inventory, err := s.Client.GetInventory()
+
+	s.Require().Nil(err, "Error getting inventory")
+	s.Require().Equal("bigip1.example.com", inventory.Hostname)
+	s.Require().Equal("BIG-IP", inventory.Product)
+	s.Require().Equal("BIG-IP i5800", inventory.MarketingName)
+	s.Require().Equal("C119", inventory.Platform)
+	s.Require().Equal("f5-abcd-efgh", inventory.SerialNumber)
+	s.Require().Equal("f5-abcd-efgh", inventory.ChassisId)
+	s.Require().Equal("00:94:a1:01:02:03", inventory.BaseMac)
+	s.Require().Equal("14.1.2.3", inventory.Version)
+	s.Require().Equal("0.0.5", inventory.Build)
+	s.Require().Equal("Point Release 3", inventory.Edition)
+	s.Require().Equal(int64(8), inventory.CpuCount)
+	s.Require().Equal(int64(33421996032), inventory.MemoryTotal)
+	s.Require().Equal([]LogicalDisk{{Name: "HD1", Mode: "mixed", Size: 476940, VgFree: 314932, VgInUse: 162008, VgReserved: 30720}}, inventory.Disks)
+	s.Require().Empty(inventory.Blades)
+	s.Require().Len(inventory.Interfaces, 1)
+	s.Require().Equal("1.1", inventory.Interfaces[0].Name)
+}
+
+func (s *SysTestSuite) TestHostInfo() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"entries": {
+				"https://localhost/mgmt/tm/sys/host-info/0": {
+					"nestedStats": {
+						"entries": {
+							"activeCpuCount": {"value": 4},
+							"cpuCount": {"value": 4},
+							"hostId": {"description": "0"},
+							"memoryTotal": {"value": 16743874560},
+							"memoryUsed": {"value": 2934681600}
+						}
+					}
+				}
+			}
+		}`))
+	}
+
+	info, err := s.Client.HostInfo()
+
+	s.Require().Nil(err, "Error getting host info")
+	s.Require().Equal("/mgmt/tm/sys/host-info", s.LastRequest.URL.Path)
+	s.Require().Equal(&HostInfo{CpuCount: 4, ActiveCpuCount: 4, MemoryTotal: 16743874560, MemoryUsed: 2934681600}, info)
+}
+
+func (s *SysTestSuite) TestTMMInfo() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"entries": {
+				"https://localhost/mgmt/tm/sys/tmm-info/0.0": {
+					"nestedStats": {
+						"entries": {
+							"cpuId": {"value": 0},
+							"fiveMinAvgUsageRatio": {"value": 3},
+							"fiveSecAvgUsageRatio": {"value": 2},
+							"memoryTotal": {"value": 1761607680},
+							"memoryUsed": {"value": 215089152},
+							"oneMinAvgUsageRatio": {"value": 4},
+							"oneSecAvgUsageRatio": {"value": 1},
+							"tmmId": {"description": "0.0"}
+						}
+					}
+				}
+			}
+		}`))
+	}
+
+	tmms, err := s.Client.TMMInfo()
+
+	s.Require().Nil(err, "Error getting tmm info")
+	s.Require().Equal("/mgmt/tm/sys/tmm-info", s.LastRequest.URL.Path)
+	s.Require().Equal([]TMMInfo{{
+		TmmId:           "0.0",
+		OneSecAvgUsage:  1,
+		FiveSecAvgUsage: 2,
+		OneMinAvgUsage:  4,
+		FiveMinAvgUsage: 3,
+		MemoryTotal:     1761607680,
+		MemoryUsed:      215089152,
+	}}, tmms)
+}
+
+func (s *SysTestSuite) TestMemoryStats() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"entries": {
+				"https://localhost/mgmt/tm/sys/memory/memory-host": {
+					"nestedStats": {
+						"entries": {
+							"https://localhost/mgmt/tm/sys/memory/memory-host/0": {
+								"nestedStats": {
+									"entries": {
+										"hostId": {"description": "0"},
+										"memoryTotal": {"value": 16743874560},
+										"memoryUsed": {"value": 2934681600},
+										"otherMemoryTotal": {"value": 4000000000},
+										"otherMemoryUsed": {"value": 2500000000},
+										"swapTotal": {"value": 1073741824},
+										"swapUsed": {"value": 0},
+										"tmmMemoryTotal": {"value": 12743874560},
+										"tmmMemoryUsed": {"value": 434681600}
+									}
+								}
+							}
+						}
+					}
+				},
+				"https://localhost/mgmt/tm/sys/memory/memory-tmm": {
+					"nestedStats": {
+						"entries": {}
+					}
+				}
+			}
+		}`))
+	}
+
+	memory, err := s.Client.MemoryStats()
+
+	s.Require().Nil(err, "Error getting memory stats")
+	s.Require().Equal("/mgmt/tm/sys/memory", s.LastRequest.URL.Path)
+	s.Require().Equal(&MemoryStats{
+		MemoryTotal:      16743874560,
+		MemoryUsed:       2934681600,
+		TmmMemoryTotal:   12743874560,
+		TmmMemoryUsed:    434681600,
+		OtherMemoryTotal: 4000000000,
+		OtherMemoryUsed:  2500000000,
+		SwapTotal:        1073741824,
+	}, memory)
+}
+
+func (s *SysTestSuite) TestPerformanceStats() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"entries": {
+				"https://localhost/mgmt/tm/sys/performance/all-stats/Client%20Bits%20In": {
+					"nestedStats": {
+						"entries": {
+							"Average": {"description": "12.5K"},
+							"Current": {"description": "2.1M"},
+							"Graph%20Name": {"description": "Throughput(bits)(bits/sec)"},
+							"Max(since%2002%2F03%2F2020)": {"description": "3G"}
+						}
+					}
+				},
+				"https://localhost/mgmt/tm/sys/performance/all-stats/Connections": {
+					"nestedStats": {
+						"entries": {
+							"Average": {"description": "42"},
+							"Current": {"description": "40"},
+							"Graph%20Name": {"description": "Active Connections"},
+							"Max(since%2002%2F03%2F2020)": {"description": "97"}
+						}
+					}
+				}
+			}
+		}`))
+	}
+
+	metrics, err := s.Client.PerformanceStats()
+
+	s.Require().Nil(err, "Error getting performance stats")
+	s.Require().Equal("/mgmt/tm/sys/performance/all-stats", s.LastRequest.URL.Path)
+	s.Require().Equal([]PerformanceMetric{
+		{Name: "Connections", Graph: "Active Connections", Current: 40, Average: 42, Max: 97},
+		{Name: "Client Bits In", Graph: "Throughput(bits)(bits/sec)", Current: 2.1e6, Average: 12.5e3, Max: 3e9},
+	}, metrics)
+}
+
+func cpuStats(user, system, idle int64) string {
+	return fmt.Sprintf(`{
+		"entries": {
+			"https://localhost/mgmt/tm/sys/cpu/0": {
+				"nestedStats": {
+					"entries": {
+						"hostId": {"description": "0"},
+						"https://localhost/mgmt/tm/sys/cpu/0/cpuInfo": {
+							"nestedStats": {
+								"entries": {
+									"https://localhost/mgmt/tm/sys/cpu/0/cpuInfo/0": {
+										"nestedStats": {
+											"entries": {
+												"cpuId": {"value": 0},
+												"idle": {"value": %d},
+												"system": {"value": %d},
+												"user": {"value": %d}
+											}
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}`, idle, system, user)
+}
+
+func (s *SysTestSuite) TestMeasurePerformance() {
+	samples := 0
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mgmt/tm/sys/cpu":
+			samples++
+			if samples == 1 {
+				w.Write([]byte(cpuStats(1000, 500, 8500)))
+			} else {
+				w.Write([]byte(cpuStats(1020, 505, 8575)))
+			}
+		case "/mgmt/tm/sys/tmm-traffic":
+			total := int64(samples) * 1000
+			w.Write([]byte(fmt.Sprintf(`{
+				"entries": {
+					"https://localhost/mgmt/tm/sys/tmm-traffic/0.0": {
+						"nestedStats": {
+							"entries": {
+								"clientSideTraffic.bitsIn": {"value": %d},
+								"clientSideTraffic.totConns": {"value": %d},
+								"tmmId": {"description": "0.0"}
+							}
+						}
+					}
+				}
+			}`, total, total/100)))
+		}
+	}
+
+	first, err := s.Client.SamplePerformance()
+	s.Require().Nil(err, "Error sampling performance")
+	second, err := s.Client.SamplePerformance()
+	s.Require().Nil(err, "Error sampling performance")
+	second.Time = first.Time.Add(10 * time.Second)
+
+	rates := second.RatesSince(first)
+
+	s.Require().Equal(10*time.Second, rates.Interval)
+	s.Require().InDelta(25.0, rates.CPUUsage, 0.001)
+	s.Require().InDelta(100.0, rates.ClientBitsInPerSec, 0.001)
+	s.Require().InDelta(1.0, rates.ClientConnsPerSec, 0.001)
+	s.Require().Zero(rates.ServerBitsInPerSec)
+}
+
+// bashOutput returns a util/bash response with <output> and exit code <code>.
+func bashOutput(output string, code int) []byte {
+	resp, _ := json.Marshal(UtilCommand{
+		Command:       "run",
+		CommandResult: fmt.Sprintf("%s\n%s%d", output, bashExitMarker, code),
+	})
+	return resp
+}
+
+const ltmLog = `Jan 31 23:59:58 bigip1 notice mcpd[6734]: 01070417:5: AUDIT - client tmsh, user admin - transaction #123
+Feb  1 00:00:01 bigip1 warning tmm1[19830]: 01010029:4: Clock advanced by 104 ticks
+Feb  1 00:00:05 bigip1 err mcpd[6734]: 01070734:3: Configuration error: pool /Common/web does not exist
+Feb  1 00:00:09 bigip1 info sod[5618]: 010c0044:6: Command: running`
+
+func (s *SysTestSuite) TestLogs() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bashOutput(ltmLog, 0))
+	}
+
+	since := time.Date(time.Now().Year(), time.February, 1, 0, 0, 0, 0, time.UTC)
+	if since.After(time.Now()) {
+		since = since.AddDate(-1, 0, 0)
+	}
+	entries, err := s.Client.Logs(&LogQuery{
+		Since:    since,
+		Severity: LogSeverityWarning,
+	})
+
+	s.Require().Nil(err, "Error reading logs")
+	var cmd UtilCommand
+	s.Require().Nil(json.Unmarshal([]byte(s.LastRequestBody), &cmd))
+	s.Require().Contains(cmd.UtilCmdArgs, "(tail -n 1000 /var/log/ltm)")
+	s.Require().Len(entries, 2)
+	s.Require().Equal("tmm1", entries[0].Process)
+	s.Require().Equal(19830, entries[0].Pid)
+	s.Require().Equal("bigip1", entries[1].Host)
+	s.Require().Equal(LogSeverityError, entries[1].Severity)
+	s.Require().Equal(5, entries[1].Time.Second())
+	s.Require().Equal("01070734:3: Configuration error: pool /Common/web does not exist", entries[1].Message)
+
+	entries, err = s.Client.Logs(&LogQuery{Pattern: regexp.MustCompile(`^01070417:`)})
+	s.Require().Nil(err, "Error reading logs")
+	s.Require().Len(entries, 1)
+	s.Require().Equal(LogSeverityNotice, entries[0].Severity)
+
+	_, err = s.Client.Logs(&LogQuery{Severity: "loud"})
+	s.Require().Error(err)
+}
+
+func (s *SysTestSuite) TestLogsNilQuery() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bashOutput(ltmLog, 0))
+	}
+
+	entries, err := s.Client.Logs(nil)
+
+	s.Require().Nil(err, "Error reading logs")
+	var cmd UtilCommand
+	s.Require().Nil(json.Unmarshal([]byte(s.LastRequestBody), &cmd))
+	s.Require().Contains(cmd.UtilCmdArgs, "(tail -n 1000 /var/log/ltm)")
+	s.Require().Len(entries, 4)
+}
+
+func (s *SysTestSuite) TestLogsTruncated() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bashOutput(ltmLog, 0))
+	}
+
+	oldest := time.Date(time.Now().Year(), time.January, 31, 23, 59, 58, 0, time.UTC)
+	if oldest.After(time.Now()) {
+		oldest = oldest.AddDate(-1, 0, 0)
+	}
+	entries, err := s.Client.Logs(&LogQuery{Lines: 4, Since: oldest.Add(-time.Hour)})
+
+	s.Require().Error(err)
+	truncated, ok := err.(*LogTruncatedError)
+	s.Require().True(ok, "Expected a LogTruncatedError")
+	s.Require().Equal(oldest, truncated.Oldest)
+	s.Require().Len(entries, 4)
+
+	// The file has fewer lines than requested, so it was read completely.
+	_, err = s.Client.Logs(&LogQuery{Lines: 5, Since: oldest.Add(-time.Hour)})
+	s.Require().Nil(err, "Error reading logs")
+}
+
+func (s *SysTestSuite) TestLogsMissingFile() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bashOutput("tail: cannot open '/var/log/missing' for reading: No such file or directory", 1))
+	}
+
+	_, err := s.Client.Logs(&LogQuery{File: "/var/log/missing"})
+
+	s.Require().Error(err)
+	s.Require().Contains(err.Error(), "No such file or directory")
+}
+
+func TestParseLog(t *testing.T) {
+	now := time.Date(2026, time.January, 2, 0, 0, 0, 0, time.UTC)
+	entries := parseLog(`[I][8123][02 Jan 2026 00:00:00 UTC][8100/shared/authn/login AuthnWorker] login failed
+[S][8124][01 Jan 2026 23:59:59 UTC][RestOperation] unexpected failure
+java.lang.NullPointerException
+	at com.f5.rest.Worker.run(Worker.java:42)
+2026-01-01T23:59:59.123456+00:00 bigip1 info systemd[1]: Started session`, time.UTC, now)
+
+	require.Len(t, entries, 3)
+	assert.Equal(t, LogEntry{
+		Time:     now,
+		Severity: LogSeverityInfo,
+		Process:  "restjavad",
+		Message:  "[8100/shared/authn/login AuthnWorker] login failed",
+	}, entries[0])
+	assert.Equal(t, LogSeverityError, entries[1].Severity)
+	assert.Equal(t, "[RestOperation] unexpected failure\njava.lang.NullPointerException\n\tat com.f5.rest.Worker.run(Worker.java:42)", entries[1].Message)
+	assert.Equal(t, "systemd", entries[2].Process)
+	assert.Equal(t, 123456000, entries[2].Time.Nanosecond())
+
+	entries = parseLog("[C][8125][02 Jan 2026 00:00:00 UTC][Config] loaded", time.UTC, now)
+	require.Len(t, entries, 1)
+	assert.Equal(t, LogSeverityInfo, entries[0].Severity)
+
+	// Syslog has no year, December entries read in January are from last year.
+	entries = parseLog("Dec 31 23:59:59 bigip1 notice mcpd[6734]: saved", time.UTC, now)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 2025, entries[0].Time.Year())
+}
+
+func (s *SysTestSuite) TestSNMP() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"kind": "tm:sys:snmp:snmpstate",
+			"agentAddresses": ["tcp6:161", "udp6:161"],
+			"agentTrap": "enabled",
+			"allowedAddresses": ["127.0.0.0/8", "10.0.0.0/8"],
+			"authTrap": "disabled",
+			"bigipTraps": "enabled",
+			"sysContact": "noc@example.com",
+			"sysLocation": "dc1"
+		}`))
+	}
+
+	snmp, err := s.Client.SNMP()
+
+	s.Require().Nil(err, "Error getting snmp")
+	s.Require().Equal("/mgmt/tm/sys/snmp", s.LastRequest.URL.Path)
+	s.Require().Equal([]string{"127.0.0.0/8", "10.0.0.0/8"}, snmp.AllowedAddresses)
+	s.Require().True(*snmp.AgentTrap)
+	s.Require().False(*snmp.AuthTrap)
+	s.Require().Equal("dc1", snmp.SysLocation)
+}
+
+func (s *SysTestSuite) TestSetSNMP() {
+	authTrap := true
+	err := s.Client.SetSNMP(&SNMP{AuthTrap: &authTrap, SysContact: "noc@example.com"})
+
+	s.Require().Nil(err, "Error setting snmp")
+	s.Require().Equal("PATCH", s.LastRequest.Method)
+	s.Require().JSONEq(`{"authTrap": "enabled", "sysContact": "noc@example.com"}`, s.LastRequestBody)
+
+	err = s.Client.SetSNMPAllowedAddresses()
+
+	s.Require().Nil(err, "Error setting snmp allowed addresses")
+	s.Require().JSONEq(`{"allowedAddresses": []}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestCreateSNMPCommunity() {
+	err := s.Client.CreateSNMPCommunity("monitoring", "s3cret", SnmpReadOnly)
+
+	s.Require().Nil(err, "Error creating snmp community")
+	s.Require().Equal("POST", s.LastRequest.Method)
+	s.Require().Equal("/mgmt/tm/sys/snmp/communities", s.LastRequest.URL.Path)
+	s.Require().JSONEq(`{"name": "monitoring", "communityName": "s3cret", "access": "ro"}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestGetSNMPCommunity() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "monitoring", "fullPath": "/Common/monitoring", "access": "ro", "communityName": "s3cret", "ipv6": "disabled", "source": "default"}`))
+	}
+
+	community, err := s.Client.GetSNMPCommunity("/Common/monitoring")
+
+	s.Require().Nil(err, "Error getting snmp community")
+	s.Require().Equal("/mgmt/tm/sys/snmp/communities/~Common~monitoring", s.LastRequest.URL.Path)
+	s.Require().Equal("s3cret", community.CommunityName)
+	s.Require().False(*community.Ipv6)
+}
+
+func (s *SysTestSuite) TestCreateSNMPUser() {
+	err := s.Client.CreateSNMPUser("monitor", "authpass", "privpass")
+
+	s.Require().Nil(err, "Error creating snmp user")
+	s.Require().Equal("/mgmt/tm/sys/snmp/users", s.LastRequest.URL.Path)
+	s.Require().JSONEq(`{
+		"name": "monitor",
+		"username": "monitor",
+		"access": "ro",
+		"authProtocol": "sha",
+		"authPassword": "authpass",
+		"privacyProtocol": "aes",
+		"privacyPassword": "privpass"
+	}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestSNMPTraps() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items": [{"name": "nms", "host": "10.1.1.20", "port": 162, "community": "traps", "network": "management", "version": "2c"}]}`))
+	}
+
+	traps, err := s.Client.SNMPTraps()
+
+	s.Require().Nil(err, "Error getting snmp traps")
+	s.Require().Equal("/mgmt/tm/sys/snmp/traps", s.LastRequest.URL.Path)
+	s.Require().Equal([]SNMPTrap{{Name: "nms", Host: "10.1.1.20", Port: 162, Community: "traps", Network: "management", Version: "2c"}}, traps.SNMPTraps)
+}
+
+func (s *SysTestSuite) TestCreateSNMPTrap() {
+	err := s.Client.CreateSNMPTrap("nms", "10.1.1.20", 162, "traps")
+
+	s.Require().Nil(err, "Error creating snmp trap")
+	s.Require().Equal("POST", s.LastRequest.Method)
+	s.Require().Equal("/mgmt/tm/sys/snmp/traps", s.LastRequest.URL.Path)
+	s.Require().JSONEq(`{"name": "nms", "host": "10.1.1.20", "port": 162, "community": "traps", "version": "2c"}`, s.LastRequestBody)
+}
+
+func (s *SysTestSuite) TestDeleteSNMPUser() {
+	err := s.Client.DeleteSNMPUser("/Common/monitor")
+
+	s.Require().Nil(err, "Error deleting snmp user")
+	s.Require().Equal("DELETE", s.LastRequest.Method)
+	s.Require().Equal("/mgmt/tm/sys/snmp/users/~Common~monitor", s.LastRequest.URL.Path)
+}
@@ -0,0 +1,131 @@
+package bigip
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	uriUtil   = "util"
+	uriBash   = "bash"
+	uriUnixLs = "unix-ls"
+	uriUnixRm = "unix-rm"
+	uriUnixMv = "unix-mv"
+	uriPing   = "ping"
+	uriDig    = "dig"
+
+	// Appended to bash commands so the exit status survives the trip through
+	// the API, which only returns the command output.
+	bashExitMarker = "__go_bigip_exit_status__"
+)
+
+// UtilCommand is the request and response body used by the util endpoints.
+type UtilCommand struct {
+	Command       string `json:"command,omitempty"`
+	UtilCmdArgs   string `json:"utilCmdArgs,omitempty"`
+	CommandResult string `json:"commandResult,omitempty"`
+}
+
+// CommandResult contains the output of a command run on the BIG-IP system.
+// ExitCode is only reported by RunBash and the helpers built on it; the other
+// util endpoints return an error instead of a non-zero exit code.
+type CommandResult struct {
+	Output   string
+	ExitCode int
+}
+
+var safeArg = regexp.MustCompile(`^[A-Za-z0-9@%_+=:,./-]+$`)
+
+// quoteArg quotes a single argument so it is passed through unchanged. Plain
+// arguments are left alone to keep the command readable.
+func quoteArg(arg string) string {
+	if safeArg.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+// quoteArgs quotes each argument and joins them into a utilCmdArgs string.
+func quoteArgs(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = quoteArg(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// runUtil runs the given util endpoint with already quoted arguments.
+func (b *BigIP) runUtil(util, args string) (*CommandResult, error) {
+	config := &UtilCommand{
+		Command:     "run",
+		UtilCmdArgs: args,
+	}
+	var resp UtilCommand
+	if err := b.postForEntity(&resp, config, uriUtil, util); err != nil {
+		return nil, err
+	}
+
+	return &CommandResult{Output: resp.CommandResult}, nil
+}
+
+// RunBash runs <command> with bash -c on the BIG-IP system and returns its
+// output together with the exit code.
+func (b *BigIP) RunBash(command string) (*CommandResult, error) {
+	script := fmt.Sprintf("(%s); printf '\\n%s%%d' $?", command, bashExitMarker)
+	result, err := b.runUtil(uriBash, "-c "+quoteArg(script))
+	if err != nil {
+		return nil, err
+	}
+
+	i := strings.LastIndex(result.Output, "\n"+bashExitMarker)
+	if i < 0 {
+		return nil, errors.New("missing exit status in bash output")
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(result.Output[i+len(bashExitMarker)+1:]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid exit status in bash output: %s", err)
+	}
+	result.Output = result.Output[:i]
+	result.ExitCode = code
+
+	return result, nil
+}
+
+// RunBashArgs runs the program <name> with the given arguments. Each argument
+// is quoted, so it may contain spaces or shell metacharacters.
+func (b *BigIP) RunBashArgs(name string, args ...string) (*CommandResult, error) {
+	return b.RunBash(quoteArgs(append([]string{name}, args...)...))
+}
+
+// RunTmsh runs a tmsh command, i.e.: "show sys version", and returns its output.
+func (b *BigIP) RunTmsh(command string) (*CommandResult, error) {
+	return b.RunBashArgs("tmsh", "-q", "-c", command)
+}
+
+// UnixLs lists the contents of <path> on the BIG-IP system.
+func (b *BigIP) UnixLs(path string) (*CommandResult, error) {
+	return b.runUtil(uriUnixLs, quoteArg(path))
+}
+
+// UnixRm removes the file at <path> on the BIG-IP system.
+func (b *BigIP) UnixRm(path string) (*CommandResult, error) {
+	return b.runUtil(uriUnixRm, quoteArg(path))
+}
+
+// UnixMv moves the file at <src> to <dst> on the BIG-IP system.
+func (b *BigIP) UnixMv(src, dst string) (*CommandResult, error) {
+	return b.runUtil(uriUnixMv, quoteArgs(src, dst))
+}
+
+// Ping sends <count> ICMP echo requests to <host> from the BIG-IP system.
+func (b *BigIP) Ping(host string, count int) (*CommandResult, error) {
+	return b.runUtil(uriPing, quoteArgs("-c", strconv.Itoa(count), host))
+}
+
+// Dig runs a DNS lookup from the BIG-IP system, i.e.: Dig("@10.1.1.53", "example.com", "mx").
+func (b *BigIP) Dig(args ...string) (*CommandResult, error) {
+	return b.runUtil(uriDig, quoteArgs(args...))
+}
@@ -0,0 +1,117 @@
+package bigip
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UtilTestSuite struct {
+	suite.Suite
+	Client          *BigIP
+	Server          *httptest.Server
+	LastRequest     *http.Request
+	LastRequestBody string
+	ResponseFunc    func(http.ResponseWriter, *http.Request)
+}
+
+func (s *UtilTestSuite) SetupSuite() {
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		s.LastRequestBody = string(body)
+		s.LastRequest = r
+		if s.ResponseFunc != nil {
+			s.ResponseFunc(w, r)
+		}
+	}))
+
+	s.Client = NewSession(s.Server.URL, "", "", nil)
+}
+
+func (s *UtilTestSuite) TearDownSuite() {
+	s.Server.Close()
+}
+
+func (s *UtilTestSuite) SetupTest() {
+	s.ResponseFunc = nil
+	s.LastRequest = nil
+}
+
+func TestUtilSuite(t *testing.T) {
+	suite.Run(t, new(UtilTestSuite))
+}
+
+func (s *UtilTestSuite) TestQuoteArgs() {
+	s.Require().Equal("/var/tmp/file.txt", quoteArg("/var/tmp/file.txt"))
+	s.Require().Equal("''", quoteArg(""))
+	s.Require().Equal(`'a b'`, quoteArg("a b"))
+	s.Require().Equal(`'it'\''s; rm -rf /'`, quoteArg("it's; rm -rf /"))
+	s.Require().Equal(`-c 3 'host name'`, quoteArgs("-c", "3", "host name"))
+}
+
+func (s *UtilTestSuite) TestRunBash() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"kind": "tm:util:bash:runstate", "command": "run", "commandResult": "line 1\nline 2\n\n` + bashExitMarker + `3"}`))
+	}
+
+	result, err := s.Client.RunBash("cat /config/bigip.conf; exit 3")
+
+	s.Require().Nil(err, "Error running bash command")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriUtil, uriBash), s.LastRequest.URL.Path, "Wrong uri to run bash")
+	s.Require().Equal("POST", s.LastRequest.Method)
+	s.Require().JSONEq(`{"command": "run", "utilCmdArgs": "-c '(cat /config/bigip.conf; exit 3); printf '\\''\\n`+bashExitMarker+`%d'\\'' $?'"}`, s.LastRequestBody)
+	s.Require().Equal("line 1\nline 2\n", result.Output)
+	s.Require().Equal(3, result.ExitCode)
+}
+
+func (s *UtilTestSuite) TestRunBashMissingExitStatus() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"command": "run", "commandResult": "truncated"}`))
+	}
+
+	_, err := s.Client.RunBash("true")
+
+	s.Require().Error(err)
+}
+
+func (s *UtilTestSuite) TestRunTmsh() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"command": "run", "commandResult": "Sys::Version\n` + bashExitMarker + `0"}`))
+	}
+
+	result, err := s.Client.RunTmsh("show sys version")
+
+	s.Require().Nil(err, "Error running tmsh command")
+	s.Require().JSONEq(`{"command": "run", "utilCmdArgs": "-c '(tmsh -q -c '\\''show sys version'\\''); printf '\\''\\n`+bashExitMarker+`%d'\\'' $?'"}`, s.LastRequestBody)
+	s.Require().Equal("Sys::Version", result.Output)
+	s.Require().Equal(0, result.ExitCode)
+}
+
+func (s *UtilTestSuite) TestUnixMv() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"command": "run"}`))
+	}
+
+	_, err := s.Client.UnixMv("/var/config/rest/downloads/my file.ucs", "/var/local/ucs/my file.ucs")
+
+	s.Require().Nil(err, "Error moving file")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriUtil, uriUnixMv), s.LastRequest.URL.Path, "Wrong uri to move file")
+	s.Require().JSONEq(`{"command": "run", "utilCmdArgs": "'/var/config/rest/downloads/my file.ucs' '/var/local/ucs/my file.ucs'"}`, s.LastRequestBody)
+}
+
+func (s *UtilTestSuite) TestPing() {
+	s.ResponseFunc = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"command": "run", "commandResult": "PING 10.1.1.1 (10.1.1.1) 56(84) bytes of data."}`))
+	}
+
+	result, err := s.Client.Ping("10.1.1.1", 2)
+
+	s.Require().Nil(err, "Error running ping")
+	s.Require().Equal(fmt.Sprintf("/mgmt/tm/%s/%s", uriUtil, uriPing), s.LastRequest.URL.Path, "Wrong uri to ping")
+	s.Require().JSONEq(`{"command": "run", "utilCmdArgs": "-c 2 10.1.1.1"}`, s.LastRequestBody)
+	s.Require().Equal("PING 10.1.1.1 (10.1.1.1) 56(84) bytes of data.", result.Output)
+}
@@ -0,0 +1,155 @@
+package bigip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TMOSVersion is the version of the software running on a BIG-IP system,
+// i.e. 14.1.2.3 build 0.0.5.
+type TMOSVersion struct {
+	Major       int
+	Minor       int
+	Maintenance int
+	Point       int
+	Build       string
+}
+
+// Capability is a feature of the API that is only available from a given
+// TMOS version on.
+type Capability struct {
+	Name       string
+	MinVersion TMOSVersion
+}
+
+var (
+	// CapabilityPolicyDrafts is the draft/publish workflow of LTM policies.
+	CapabilityPolicyDrafts = Capability{"draft/publish policies", TMOSVersion{Major: 12, Minor: 1}}
+	// CapabilityTrafficMatchingCriteria is ltm traffic-matching-criteria.
+	CapabilityTrafficMatchingCriteria = Capability{"traffic-matching-criteria", TMOSVersion{Major: 14, Minor: 1}}
+)
+
+// UnsupportedVersionError is returned when a function needs a capability the
+// system does not have.
+type UnsupportedVersionError struct {
+	Capability Capability
+	Version    TMOSVersion
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("%s requires TMOS %s or later, the system runs %s",
+		e.Capability.Name, e.Capability.MinVersion, e.Version)
+}
+
+// ParseTMOSVersion parses a version such as "14.1.2.3". Missing components
+// are zero.
+func ParseTMOSVersion(version string) (*TMOSVersion, error) {
+	parts := strings.Split(strings.TrimSpace(version), ".")
+	if len(parts) < 2 || len(parts) > 4 {
+		return nil, fmt.Errorf("invalid TMOS version %q", version)
+	}
+	var numbers [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid TMOS version %q", version)
+		}
+		numbers[i] = n
+	}
+
+	return &TMOSVersion{
+		Major:       numbers[0],
+		Minor:       numbers[1],
+		Maintenance: numbers[2],
+		Point:       numbers[3],
+	}, nil
+}
+
+// String returns the version without the build, omitting trailing zeros
+// after the minor version.
+func (v TMOSVersion) String() string {
+	s := fmt.Sprintf("%d.%d", v.Major, v.Minor)
+	if v.Maintenance != 0 || v.Point != 0 {
+		s += fmt.Sprintf(".%d", v.Maintenance)
+	}
+	if v.Point != 0 {
+		s += fmt.Sprintf(".%d", v.Point)
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is older than, the same as or newer than
+// <other>. Builds are not compared.
+func (v TMOSVersion) Compare(other TMOSVersion) int {
+	a := [4]int{v.Major, v.Minor, v.Maintenance, v.Point}
+	b := [4]int{other.Major, other.Minor, other.Maintenance, other.Point}
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// AtLeast returns true if v is the same as or newer than <other>.
+func (v TMOSVersion) AtLeast(other TMOSVersion) bool {
+	return v.Compare(other) >= 0
+}
+
+// TMOSVersion returns the version of the system. It is detected on first use
+// and cached until the system is rebooted through the session.
+func (b *BigIP) TMOSVersion() (*TMOSVersion, error) {
+	b.versionMu.Lock()
+	defer b.versionMu.Unlock()
+
+	if b.version == nil {
+		sv, err := b.GetSystemVersion()
+		if err != nil {
+			return nil, err
+		}
+		v, err := ParseTMOSVersion(sv.Version)
+		if err != nil {
+			return nil, err
+		}
+		v.Build = sv.Build
+		b.version = v
+	}
+
+	v := *b.version
+	return &v, nil
+}
+
+// resetTMOSVersion drops the cached version so it is detected again.
+func (b *BigIP) resetTMOSVersion() {
+	b.versionMu.Lock()
+	defer b.versionMu.Unlock()
+	b.version = nil
+}
+
+// Supports returns true if the system has capability <c>.
+func (b *BigIP) Supports(c Capability) (bool, error) {
+	v, err := b.TMOSVersion()
+	if err != nil {
+		return false, err
+	}
+
+	return v.AtLeast(c.MinVersion), nil
+}
+
+// requireCapability returns an UnsupportedVersionError if the system does
+// not have capability <c>.
+func (b *BigIP) requireCapability(c Capability) error {
+	v, err := b.TMOSVersion()
+	if err != nil {
+		return err
+	}
+	if !v.AtLeast(c.MinVersion) {
+		return &UnsupportedVersionError{Capability: c, Version: *v}
+	}
+
+	return nil
+}
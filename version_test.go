@@ -0,0 +1,141 @@
+package bigip
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTMOSVersion(t *testing.T) {
+	v, err := ParseTMOSVersion("14.1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, &TMOSVersion{Major: 14, Minor: 1, Maintenance: 2, Point: 3}, v)
+	assert.Equal(t, "14.1.2.3", v.String())
+
+	v, err = ParseTMOSVersion("12.1")
+	require.NoError(t, err)
+	assert.Equal(t, "12.1", v.String())
+
+	for _, s := range []string{"", "14", "14.x", "1.2.3.4.5"} {
+		_, err = ParseTMOSVersion(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestTMOSVersionCompare(t *testing.T) {
+	v := TMOSVersion{Major: 13, Minor: 1, Maintenance: 3}
+	assert.True(t, v.AtLeast(TMOSVersion{Major: 12, Minor: 1}))
+	assert.True(t, v.AtLeast(TMOSVersion{Major: 13, Minor: 1, Maintenance: 3}))
+	assert.False(t, v.AtLeast(TMOSVersion{Major: 13, Minor: 1, Maintenance: 3, Point: 1}))
+	assert.False(t, v.AtLeast(CapabilityTrafficMatchingCriteria.MinVersion))
+	assert.Equal(t, -1, v.Compare(TMOSVersion{Major: 14}))
+}
+
+// versionServer serves sys/version as <version> and records all other
+// requests.
+func versionServer(version string, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mgmt/tm/sys/version" {
+			w.Write([]byte(strings.Replace(sysVersion, "14.1.2.3", version, 1)))
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		*requests = append(*requests, strings.TrimSpace(r.Method+" "+r.URL.RequestURI()+" "+string(body)))
+		if r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/mgmt/tm/ltm/policy/") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code": 404, "message": "Object not found"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+}
+
+func TestTMOSVersionDetectedOnce(t *testing.T) {
+	detections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		detections++
+		w.Write([]byte(sysVersion))
+	}))
+	defer server.Close()
+
+	b := NewSession(server.URL, "admin", "secret", nil)
+	v, err := b.TMOSVersion()
+	require.NoError(t, err)
+	assert.Equal(t, &TMOSVersion{Major: 14, Minor: 1, Maintenance: 2, Point: 3, Build: "0.0.5"}, v)
+
+	ok, err := b.Supports(CapabilityTrafficMatchingCriteria)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, detections)
+}
+
+func TestTMOSVersionDetectedAfterReboot(t *testing.T) {
+	version := "13.1.3"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mgmt/tm/sys/version" {
+			w.Write([]byte(strings.Replace(sysVersion, "14.1.2.3", version, 1)))
+			return
+		}
+		// The system reboots into the upgraded volume.
+		version = "14.1.2.3"
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	b := NewSession(server.URL, "admin", "secret", nil)
+	ok, err := b.Supports(CapabilityTrafficMatchingCriteria)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, b.RebootToVolume("HD1.2"))
+	ok, err = b.Supports(CapabilityTrafficMatchingCriteria)
+	require.NoError(t, err)
+	assert.True(t, ok, "Version not detected again after the reboot")
+}
+
+func TestUnsupportedVersion(t *testing.T) {
+	var requests []string
+	server := versionServer("13.1.3", &requests)
+	defer server.Close()
+
+	b := NewSession(server.URL, "admin", "secret", nil)
+	err := b.AddTrafficMatchingCriteria(&TrafficMatchingCriteria{Name: "tmc"})
+	require.Error(t, err)
+	assert.IsType(t, &UnsupportedVersionError{}, err)
+	assert.Equal(t, "traffic-matching-criteria requires TMOS 14.1 or later, the system runs 13.1.3", err.Error())
+	assert.Empty(t, requests)
+}
+
+func TestSavePolicyWithDrafts(t *testing.T) {
+	var requests []string
+	server := versionServer("14.1.2.3", &requests)
+	defer server.Close()
+
+	b := NewSession(server.URL, "admin", "secret", nil)
+	err := b.SavePolicy(&Policy{Name: "forward", Strategy: "first-match"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"GET /mgmt/tm/ltm/policy/~Common~forward",
+		`POST /mgmt/tm/ltm/policy {"name":"/Common/Drafts/forward","strategy":"first-match","rulesReference":{}}`,
+		`POST /mgmt/tm/ltm/policy {"command":"publish","name":"/Common/Drafts/forward"}`,
+	}, requests)
+}
+
+func TestSavePolicyInPlace(t *testing.T) {
+	var requests []string
+	server := versionServer("11.6.1", &requests)
+	defer server.Close()
+
+	b := NewSession(server.URL, "admin", "secret", nil)
+	err := b.SavePolicy(&Policy{Name: "forward", Strategy: "first-match"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"GET /mgmt/tm/ltm/policy/~Common~forward",
+		`POST /mgmt/tm/ltm/policy?ver=11.5.1 {"name":"forward","strategy":"first-match","rulesReference":{}}`,
+	}, requests)
+}